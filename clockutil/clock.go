@@ -0,0 +1,60 @@
+package clockutil
+
+import (
+	"sync"
+	"time"
+)
+
+// Clock 时间来源，测试中可替换为 FakeClock
+type Clock interface {
+	Now() time.Time
+}
+
+type realClock struct{}
+
+func (realClock) Now() time.Time { return time.Now() }
+
+// Real 系统时钟
+var Real Clock = realClock{}
+
+// OrReal c 为 nil 时返回系统时钟
+func OrReal(c Clock) Clock {
+	if c == nil {
+		return Real
+	}
+	return c
+}
+
+// FakeClock 手动推进的时钟，只有调用 Advance/Set 时时间才会变化
+type FakeClock struct {
+	mu  sync.Mutex
+	now time.Time
+}
+
+// NewFakeClock start 为零值时从当前时间开始
+func NewFakeClock(start time.Time) *FakeClock {
+	if start.IsZero() {
+		start = time.Now()
+	}
+	return &FakeClock{now: start}
+}
+
+func (c *FakeClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.now
+}
+
+// Advance 把时间向前推进 d
+func (c *FakeClock) Advance(d time.Duration) {
+	c.mu.Lock()
+	c.now = c.now.Add(d)
+	c.mu.Unlock()
+}
+
+// Set 把时间设为 t
+func (c *FakeClock) Set(t time.Time) {
+	c.mu.Lock()
+	c.now = t
+	c.mu.Unlock()
+}
@@ -0,0 +1,237 @@
+package jwtutil
+
+import (
+	"crypto/subtle"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const clientAssertionType = "urn:ietf:params:oauth:client-assertion-type:jwt-bearer"
+
+var ErrClientNotFound = errors.New("client not found")
+
+// OAuthClient 注册的机器客户端
+type OAuthClient struct {
+	ID            string
+	Secret        string   // client_secret 认证，为空表示不支持
+	Keys          KeySet   // private_key_jwt 认证使用的公钥（按 kid 查找），为 nil 表示不支持
+	AllowedScopes []string // 允许申请的 scope
+}
+
+// ClientRegistry 客户端注册表
+type ClientRegistry interface {
+	Client(id string) (*OAuthClient, error)
+}
+
+// MemoryClientRegistry 基于内存的客户端注册表，同时实现 ClientAuthenticator
+type MemoryClientRegistry struct {
+	mu      sync.RWMutex
+	clients map[string]*OAuthClient
+}
+
+func NewMemoryClientRegistry() *MemoryClientRegistry {
+	return &MemoryClientRegistry{clients: make(map[string]*OAuthClient)}
+}
+
+// Register 注册或替换客户端
+func (r *MemoryClientRegistry) Register(c *OAuthClient) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.clients[c.ID] = c
+}
+
+// Remove 删除客户端
+func (r *MemoryClientRegistry) Remove(id string) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.clients, id)
+}
+
+func (r *MemoryClientRegistry) Client(id string) (*OAuthClient, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	c, ok := r.clients[id]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrClientNotFound, id)
+	}
+	return c, nil
+}
+
+// AuthenticateClient 使用 client_secret 认证，可直接用于内省/撤销端点
+func (r *MemoryClientRegistry) AuthenticateClient(clientID, clientSecret string) error {
+	c, err := r.Client(clientID)
+	if err != nil {
+		return ErrInvalidClient
+	}
+	return c.checkSecret(clientSecret)
+}
+
+func (c *OAuthClient) checkSecret(secret string) error {
+	if c.Secret == "" || subtle.ConstantTimeCompare([]byte(c.Secret), []byte(secret)) != 1 {
+		return ErrInvalidClient
+	}
+	return nil
+}
+
+// ---------------------------
+// token 端点
+// ---------------------------
+
+// TokenEndpoint OAuth2 client_credentials 授权的 /token 端点
+// 支持 client_secret_basic、client_secret_post 与 private_key_jwt 三种客户端认证
+type TokenEndpoint struct {
+	Issuer  *JWTManager    // 签发 access token
+	Clients ClientRegistry // 客户端注册表
+	URL     string         // 本端点的完整 URL，private_key_jwt 断言的 aud 必须包含它
+
+	mu   sync.Mutex
+	used map[string]time.Time // 已使用的断言 jti，防止重放
+}
+
+func NewTokenEndpoint(issuer *JWTManager, clients ClientRegistry, endpointURL string) *TokenEndpoint {
+	return &TokenEndpoint{
+		Issuer:  issuer,
+		Clients: clients,
+		URL:     endpointURL,
+		used:    make(map[string]time.Time),
+	}
+}
+
+func (e *TokenEndpoint) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	if gt := r.PostFormValue("grant_type"); gt != "client_credentials" {
+		writeJSONError(w, http.StatusBadRequest, "unsupported_grant_type", "only client_credentials is supported")
+		return
+	}
+
+	client, err := e.authenticate(r)
+	if err != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid_client", err.Error())
+		return
+	}
+
+	scopes, err := grantScopes(client, strings.Fields(r.PostFormValue("scope")))
+	if err != nil {
+		writeJSONError(w, http.StatusBadRequest, "invalid_scope", err.Error())
+		return
+	}
+
+	claims := jwt.MapClaims{
+		"sub":       client.ID,
+		"client_id": client.ID,
+	}
+	scope := strings.Join(scopes, " ")
+	if scope != "" {
+		claims["scope"] = scope
+	}
+	token, err := e.Issuer.GenerateToken(claims)
+	if err != nil {
+		writeJSONError(w, http.StatusInternalServerError, "server_error", "failed to issue token")
+		return
+	}
+
+	resp := map[string]interface{}{
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int64(e.Issuer.TokenDuration.Seconds()),
+	}
+	if scope != "" {
+		resp["scope"] = scope
+	}
+	writeJSON(w, resp)
+}
+
+// grantScopes 未申请 scope 时授予全部允许的 scope；申请了不允许的 scope 时报错
+func grantScopes(client *OAuthClient, requested []string) ([]string, error) {
+	if len(requested) == 0 {
+		return client.AllowedScopes, nil
+	}
+	for _, s := range requested {
+		if !containsAny(client.AllowedScopes, s) {
+			return nil, fmt.Errorf("scope %q not allowed for client", s)
+		}
+	}
+	return requested, nil
+}
+
+func (e *TokenEndpoint) authenticate(r *http.Request) (*OAuthClient, error) {
+	if r.PostFormValue("client_assertion_type") != "" {
+		return e.authenticateAssertion(r)
+	}
+	id, secret, ok := clientCredentials(r)
+	if !ok {
+		return nil, ErrInvalidClient
+	}
+	client, err := e.Clients.Client(id)
+	if err != nil {
+		return nil, ErrInvalidClient
+	}
+	if err := client.checkSecret(secret); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+// authenticateAssertion RFC 7523 private_key_jwt：iss 与 sub 均为 client_id，aud 包含端点 URL，jti 只能使用一次
+func (e *TokenEndpoint) authenticateAssertion(r *http.Request) (*OAuthClient, error) {
+	if r.PostFormValue("client_assertion_type") != clientAssertionType {
+		return nil, errors.New("unsupported client_assertion_type")
+	}
+	assertion := r.PostFormValue("client_assertion")
+
+	// 先不验签读出 iss，再用该客户端的公钥校验
+	var unverified jwt.MapClaims
+	if _, _, err := jwt.NewParser().ParseUnverified(assertion, &unverified); err != nil {
+		return nil, ErrInvalidClient
+	}
+	id, _ := unverified["iss"].(string)
+	client, err := e.Clients.Client(id)
+	if err != nil || client.Keys == nil {
+		return nil, ErrInvalidClient
+	}
+
+	verifier := NewJWTVerifierWithKeySet(client.Keys, "")
+	verifier.Audience = []string{e.URL}
+	verifier.Issuer = client.ID
+	verifier.RequiredClaims = []string{"exp", "jti"}
+	claims, err := verifier.ParseToken(assertion)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidClient, err)
+	}
+	if sub, _ := claims["sub"].(string); sub != client.ID {
+		return nil, ErrInvalidClient
+	}
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims.GetExpirationTime()
+	if err := e.markUsed(client.ID+":"+jti, exp.Time); err != nil {
+		return nil, err
+	}
+	return client, nil
+}
+
+func (e *TokenEndpoint) markUsed(key string, expireAt time.Time) error {
+	now := time.Now()
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	for k, exp := range e.used {
+		if now.After(exp) {
+			delete(e.used, k)
+		}
+	}
+	if _, ok := e.used[key]; ok {
+		return fmt.Errorf("%w: assertion replayed", ErrInvalidClient)
+	}
+	e.used[key] = expireAt
+	return nil
+}
@@ -0,0 +1,118 @@
+package jwtutil
+
+import (
+	"errors"
+	"net/http"
+)
+
+// Cookie 名前缀，浏览器会强制校验对应的属性
+const (
+	CookiePrefixHost   = "__Host-"   // 要求 Secure、Path=/ 且不能设置 Domain
+	CookiePrefixSecure = "__Secure-" // 要求 Secure
+)
+
+// CookieOptions SetTokenCookie 写 Cookie 时使用的属性
+type CookieOptions struct {
+	Secure      bool
+	Domain      string
+	Path        string        // 为空时为 "/"
+	SameSite    http.SameSite // 为 0 时为 Lax
+	Partitioned bool          // CHIPS 分区 Cookie，要求 Secure
+	Prefix      string        // "", CookiePrefixHost 或 CookiePrefixSecure
+}
+
+// DefaultCookieOptions 与旧版本行为一致的默认值（生产环境应开启 Secure）
+func DefaultCookieOptions() CookieOptions {
+	return CookieOptions{
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+	}
+}
+
+// Validate 检查属性组合是否会被浏览器拒绝
+func (o CookieOptions) Validate() error {
+	if o.SameSite == http.SameSiteNoneMode && !o.Secure {
+		return errors.New("cookie: SameSite=None requires Secure")
+	}
+	if o.Partitioned && !o.Secure {
+		return errors.New("cookie: Partitioned requires Secure")
+	}
+	switch o.Prefix {
+	case "":
+	case CookiePrefixSecure:
+		if !o.Secure {
+			return errors.New("cookie: __Secure- prefix requires Secure")
+		}
+	case CookiePrefixHost:
+		if !o.Secure {
+			return errors.New("cookie: __Host- prefix requires Secure")
+		}
+		if o.Domain != "" {
+			return errors.New("cookie: __Host- prefix forbids Domain")
+		}
+		if o.Path != "" && o.Path != "/" {
+			return errors.New("cookie: __Host- prefix requires Path=/")
+		}
+	default:
+		return errors.New("cookie: unknown prefix " + o.Prefix)
+	}
+	return nil
+}
+
+// cookiePolicy JWTManager 与 PasetoManager 共用的 Cookie 属性配置
+type cookiePolicy struct {
+	opts *CookieOptions // 为 nil 时使用 DefaultCookieOptions
+}
+
+// SetCookieOptions 设置 Cookie 属性，组合非法时返回错误且不修改当前配置
+func (p *cookiePolicy) SetCookieOptions(opts CookieOptions) error {
+	if err := opts.Validate(); err != nil {
+		return err
+	}
+	if opts.Path == "" {
+		opts.Path = "/"
+	}
+	if opts.SameSite == 0 {
+		opts.SameSite = http.SameSiteLaxMode
+	}
+	p.opts = &opts
+	return nil
+}
+
+// CookieOptions 返回当前 Cookie 属性
+func (p *cookiePolicy) CookieOptions() CookieOptions {
+	if p.opts == nil {
+		return DefaultCookieOptions()
+	}
+	return *p.opts
+}
+
+// buildCookie name 不含前缀
+func (p *cookiePolicy) buildCookie(name, value string, maxAge int) *http.Cookie {
+	opts := p.CookieOptions()
+	return &http.Cookie{
+		Name:        opts.Prefix + name,
+		Value:       value,
+		HttpOnly:    true,
+		Path:        opts.Path,
+		Domain:      opts.Domain,
+		MaxAge:      maxAge,
+		Secure:      opts.Secure,
+		SameSite:    opts.SameSite,
+		Partitioned: opts.Partitioned,
+	}
+}
+
+// TokenCookieName 返回带前缀的实际 Cookie 名
+func (m *JWTManager) TokenCookieName() string {
+	return m.CookieOptions().Prefix + m.CookieName
+}
+
+func (m *JWTManager) newCookie(value string, maxAge int) *http.Cookie {
+	return m.buildCookie(m.CookieName, value, maxAge)
+}
+
+// ClearTokenCookie 删除 token Cookie（登出），属性需与写入时一致浏览器才会删除
+func (m *JWTManager) ClearTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, m.newCookie("", -1))
+}
@@ -0,0 +1,173 @@
+package jwtutil
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+var (
+	ErrCSRFTokenMissing = errors.New("csrf token missing")
+	ErrCSRFTokenInvalid = errors.New("csrf token invalid")
+	ErrOriginNotAllowed = errors.New("origin not allowed")
+)
+
+// CSRFConfig CSRF 防护配置
+type CSRFConfig struct {
+	Key            []byte   // 用于把 CSRF token 绑定到会话标识的 HMAC 密钥，必填
+	CookieName     string   // 前端可读的 Cookie 名，默认 "csrf_token"
+	HeaderName     string   // 提交与下发 CSRF token 的请求/响应头，默认 "X-CSRF-Token"
+	FormField      string   // 表单提交时的字段名，默认 "csrf_token"
+	TrustedOrigins []string // Origin/Referer 白名单，如 "https://app.example.com"，为空时不检查
+	// OnError 校验失败时的响应，为空时返回 403 JSON
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+}
+
+// CSRF 基于 double-submit 的 CSRF 防护，token 由会话标识（续签出的 token 为 sid，否则为 jti）派生
+// 只保护通过 Cookie 认证的请求；使用 Authorization 头的请求不受 CSRF 影响，直接放行
+type CSRF struct {
+	jwt *JWTManager
+	cfg CSRFConfig
+}
+
+func NewCSRF(m *JWTManager, cfg CSRFConfig) (*CSRF, error) {
+	if len(cfg.Key) == 0 {
+		return nil, errors.New("csrf: key is required")
+	}
+	if cfg.CookieName == "" {
+		cfg.CookieName = "csrf_token"
+	}
+	if cfg.HeaderName == "" {
+		cfg.HeaderName = "X-CSRF-Token"
+	}
+	if cfg.FormField == "" {
+		cfg.FormField = "csrf_token"
+	}
+	if cfg.OnError == nil {
+		cfg.OnError = func(w http.ResponseWriter, r *http.Request, err error) {
+			writeJSONError(w, http.StatusForbidden, "csrf_failed", err.Error())
+		}
+	}
+	origins := make([]string, 0, len(cfg.TrustedOrigins))
+	for _, o := range cfg.TrustedOrigins {
+		origins = append(origins, strings.TrimRight(strings.ToLower(o), "/"))
+	}
+	cfg.TrustedOrigins = origins
+	return &CSRF{jwt: m, cfg: cfg}, nil
+}
+
+// Token 计算会话标识对应的 CSRF token
+func (c *CSRF) Token(sid string) string {
+	mac := hmac.New(sha256.New, c.cfg.Key)
+	mac.Write([]byte(sid))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Issue 为会话 token 下发 CSRF token：写入可读 Cookie 并通过响应头返回
+// 一般在登录、刷新后紧跟 SetTokenCookie 调用
+// Cookie 不设过期时间（会话 Cookie），会话续签后 CSRF token 不变，不会先于会话 Cookie 过期
+func (c *CSRF) Issue(w http.ResponseWriter, sessionToken string) (string, error) {
+	claims, err := c.jwt.ParseToken(sessionToken)
+	if err != nil {
+		return "", err
+	}
+	sid := sessionID(claims)
+	if sid == "" {
+		return "", errors.New("csrf: session token has no jti")
+	}
+
+	token := c.Token(sid)
+	cookie := c.jwt.newCookie(token, 0)
+	cookie.Name = c.cfg.CookieName
+	cookie.HttpOnly = false // 前端需要读取后放入请求头
+	http.SetCookie(w, cookie)
+	w.Header().Set(c.cfg.HeaderName, token)
+	return token, nil
+}
+
+func isSafeMethod(method string) bool {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions, http.MethodTrace:
+		return true
+	}
+	return false
+}
+
+// checkOrigin 优先检查 Origin，缺失时退回 Referer
+func (c *CSRF) checkOrigin(r *http.Request) error {
+	if len(c.cfg.TrustedOrigins) == 0 {
+		return nil
+	}
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		ref, err := url.Parse(r.Header.Get("Referer"))
+		if err != nil || ref.Host == "" {
+			return ErrOriginNotAllowed
+		}
+		origin = ref.Scheme + "://" + ref.Host
+	}
+	origin = strings.ToLower(origin)
+	for _, o := range c.cfg.TrustedOrigins {
+		if o == origin {
+			return nil
+		}
+	}
+	return ErrOriginNotAllowed
+}
+
+// Middleware 在非安全方法上校验 CSRF token，可放在认证中间件之前或之后
+func (c *CSRF) Middleware() func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if isSafeMethod(r.Method) {
+				next.ServeHTTP(w, r)
+				return
+			}
+			session, err := c.jwt.ReadTokenFromCookie(r)
+			if err != nil {
+				// 没有会话 Cookie，浏览器无法借用身份，交给认证中间件处理
+				next.ServeHTTP(w, r)
+				return
+			}
+			if err := c.checkOrigin(r); err != nil {
+				c.cfg.OnError(w, r, err)
+				return
+			}
+			if err := c.verify(r, session); err != nil {
+				c.cfg.OnError(w, r, err)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func (c *CSRF) verify(r *http.Request, session string) error {
+	submitted := r.Header.Get(c.cfg.HeaderName)
+	if submitted == "" {
+		submitted = r.PostFormValue(c.cfg.FormField)
+	}
+	cookie, err := r.Cookie(c.cfg.CookieName)
+	if submitted == "" || err != nil {
+		return ErrCSRFTokenMissing
+	}
+	if !hmac.Equal([]byte(submitted), []byte(cookie.Value)) {
+		return ErrCSRFTokenInvalid
+	}
+
+	claims, ok := ClaimsFromContext(r.Context())
+	if !ok {
+		if claims, err = c.jwt.ParseToken(session); err != nil {
+			return err
+		}
+	}
+	sid := sessionID(claims)
+	if sid == "" || !hmac.Equal([]byte(submitted), []byte(c.Token(sid))) {
+		return ErrCSRFTokenInvalid
+	}
+	return nil
+}
@@ -0,0 +1,330 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// DPoP（RFC 9449）：token 通过 cnf.jkt 绑定到客户端公钥，
+// 每个请求都需附带用该私钥签名的 DPoP proof，被盗的 token 无法单独重放
+
+const (
+	DPoPHeader     = "DPoP"
+	dpopProofType  = "dpop+jwt"
+	confirmClaim   = "cnf"
+	thumbprintKey  = "jkt"
+	defaultDPoPAge = 5 * time.Minute
+)
+
+var (
+	ErrDPoPProofMissing   = errors.New("missing DPoP proof")
+	ErrInvalidDPoPProof   = errors.New("invalid DPoP proof")
+	ErrDPoPKeyMismatch    = errors.New("DPoP proof key does not match token binding")
+	ErrDPoPRequired       = errors.New("DPoP-bound token required")
+	ErrDPoPSchemeRequired = errors.New("DPoP-bound token must use the DPoP authorization scheme")
+)
+
+// Thumbprint 计算 RFC 7638 JWK 指纹（base64url(SHA-256)）
+func (j JWK) Thumbprint() (string, error) {
+	// 成员按字典序排列，encoding/json 按结构体字段顺序输出
+	var v interface{}
+	switch j.Kty {
+	case "RSA":
+		v = struct {
+			E   string `json:"e"`
+			Kty string `json:"kty"`
+			N   string `json:"n"`
+		}{j.E, j.Kty, j.N}
+	case "EC":
+		v = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+			Y   string `json:"y"`
+		}{j.Crv, j.Kty, j.X, j.Y}
+	case "OKP":
+		v = struct {
+			Crv string `json:"crv"`
+			Kty string `json:"kty"`
+			X   string `json:"x"`
+		}{j.Crv, j.Kty, j.X}
+	default:
+		return "", fmt.Errorf("unsupported kty %q", j.Kty)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(b)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
+
+// BindDPoPKey 把 DPoP 公钥指纹写入 claims 的 cnf.jkt，签发后的 token 只能配合 proof 使用
+func BindDPoPKey(claims jwt.MapClaims, jkt string) {
+	claims[confirmClaim] = map[string]interface{}{thumbprintKey: jkt}
+}
+
+// boundThumbprint 读取 claims 中的 cnf.jkt，未绑定时返回空串
+func boundThumbprint(claims jwt.MapClaims) string {
+	cnf, _ := claims[confirmClaim].(map[string]interface{})
+	jkt, _ := cnf[thumbprintKey].(string)
+	return jkt
+}
+
+// accessTokenHash proof 中的 ath：base64url(SHA-256(access token))
+func accessTokenHash(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return base64.RawURLEncoding.EncodeToString(sum[:])
+}
+
+// normalizeHTU 去掉 query 与 fragment，scheme/host 转小写
+func normalizeHTU(raw string) (string, error) {
+	u, err := url.Parse(raw)
+	if err != nil {
+		return "", err
+	}
+	if u.Scheme == "" || u.Host == "" {
+		return "", fmt.Errorf("htu %q is not absolute", raw)
+	}
+	path := u.EscapedPath()
+	if path == "" {
+		path = "/"
+	}
+	return strings.ToLower(u.Scheme) + "://" + strings.ToLower(u.Host) + path, nil
+}
+
+// ---------------------------
+// 客户端
+// ---------------------------
+
+// DPoPProver 客户端持有的 DPoP 密钥，用于生成 proof
+type DPoPProver struct {
+	method jwt.SigningMethod
+	key    crypto.Signer
+	jwk    JWK
+	jkt    string
+}
+
+// NewDPoPProver 只支持非对称算法（RS*/PS*/ES*/EdDSA）
+func NewDPoPProver(method jwt.SigningMethod, key crypto.Signer) (*DPoPProver, error) {
+	if err := checkSigner(method, key); err != nil {
+		return nil, err
+	}
+	jwk, err := NewJWK("", method, key.Public())
+	if err != nil {
+		return nil, err
+	}
+	jwk.Alg, jwk.Use = "", ""
+	jkt, err := jwk.Thumbprint()
+	if err != nil {
+		return nil, err
+	}
+	return &DPoPProver{method: method, key: key, jwk: jwk, jkt: jkt}, nil
+}
+
+// Thumbprint 公钥指纹，签发方用它调用 BindDPoPKey
+func (p *DPoPProver) Thumbprint() string {
+	return p.jkt
+}
+
+// Proof 为一次请求生成 proof；accessToken 非空时写入 ath
+func (p *DPoPProver) Proof(method, rawURL, accessToken string) (string, error) {
+	htu, err := normalizeHTU(rawURL)
+	if err != nil {
+		return "", err
+	}
+	claims := jwt.MapClaims{
+		"jti": newTokenID(),
+		"htm": method,
+		"htu": htu,
+		"iat": time.Now().Unix(),
+	}
+	if accessToken != "" {
+		claims["ath"] = accessTokenHash(accessToken)
+	}
+	token := jwt.NewWithClaims(p.method, claims)
+	token.Header["typ"] = dpopProofType
+	token.Header["jwk"] = p.jwk
+	return token.SignedString(p.key)
+}
+
+// Apply 给请求加上 Authorization: DPoP <token> 与 DPoP proof 头
+func (p *DPoPProver) Apply(req *http.Request, accessToken string) error {
+	proof, err := p.Proof(req.Method, req.URL.String(), accessToken)
+	if err != nil {
+		return err
+	}
+	if accessToken != "" {
+		req.Header.Set("Authorization", "DPoP "+accessToken)
+	}
+	req.Header.Set(DPoPHeader, proof)
+	return nil
+}
+
+// ---------------------------
+// 服务端
+// ---------------------------
+
+// DPoPVerifier 校验请求中的 DPoP proof
+type DPoPVerifier struct {
+	// MaxAge proof 的 iat 最多早于当前多久，默认 5 分钟
+	MaxAge time.Duration
+	// Leeway 允许 iat 超前的时钟偏差
+	Leeway time.Duration
+	// Replay 记录已使用过的 proof jti
+	Replay OneTimeStore
+	// Required 为 true 时拒绝未绑定 DPoP 的 token
+	Required bool
+	// RequestURL 计算请求的绝对 URL，反向代理后需自行指定
+	RequestURL func(r *http.Request) string
+}
+
+func NewDPoPVerifier() *DPoPVerifier {
+	return &DPoPVerifier{
+		MaxAge: defaultDPoPAge,
+		Leeway: 5 * time.Second,
+		Replay: NewMemoryOneTimeStore(),
+	}
+}
+
+func defaultRequestURL(r *http.Request) string {
+	scheme := "http"
+	if r.TLS != nil {
+		scheme = "https"
+	}
+	return scheme + "://" + r.Host + r.URL.EscapedPath()
+}
+
+func invalidProof(format string, args ...interface{}) error {
+	return fmt.Errorf("%w: %s", ErrInvalidDPoPProof, fmt.Sprintf(format, args...))
+}
+
+// Verify 校验 proof 的签名、htm/htu、iat、ath 与 jti 重放，返回 proof 公钥指纹
+// accessToken 为空时不校验 ath（例如 token 端点上的 proof）
+func (v *DPoPVerifier) Verify(r *http.Request, accessToken string) (string, error) {
+	values := r.Header.Values(DPoPHeader)
+	if len(values) == 0 {
+		return "", ErrDPoPProofMissing
+	}
+	if len(values) > 1 {
+		return "", invalidProof("multiple proofs")
+	}
+
+	var jwk JWK
+	token, err := jwt.Parse(values[0], func(t *jwt.Token) (interface{}, error) {
+		if t.Header["typ"] != dpopProofType {
+			return nil, errors.New("wrong typ")
+		}
+		raw, err := json.Marshal(t.Header["jwk"])
+		if err != nil {
+			return nil, err
+		}
+		var members map[string]interface{}
+		if err := json.Unmarshal(raw, &members); err != nil || members == nil {
+			return nil, errors.New("missing jwk")
+		}
+		if _, ok := members["d"]; ok {
+			return nil, errors.New("jwk contains private key")
+		}
+		if err := json.Unmarshal(raw, &jwk); err != nil {
+			return nil, err
+		}
+		key, err := jwk.Key()
+		if err != nil {
+			return nil, err
+		}
+		// 以 header 中的 alg 为准，HMAC 与 none 会在这里被拒绝
+		if err := checkKey(t.Method, key.VerifyKey); err != nil {
+			return nil, err
+		}
+		return key.VerifyKey, nil
+	})
+	if err != nil {
+		return "", invalidProof("%v", err)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+
+	jti, _ := claims["jti"].(string)
+	if jti == "" {
+		return "", invalidProof("missing jti")
+	}
+	if htm, _ := claims["htm"].(string); htm != r.Method {
+		return "", invalidProof("htm mismatch")
+	}
+	requestURL := defaultRequestURL
+	if v.RequestURL != nil {
+		requestURL = v.RequestURL
+	}
+	htu, _ := claims["htu"].(string)
+	got, err := normalizeHTU(htu)
+	if err != nil {
+		return "", invalidProof("%v", err)
+	}
+	want, err := normalizeHTU(requestURL(r))
+	if err != nil || got != want {
+		return "", invalidProof("htu mismatch")
+	}
+
+	iat, err := claims.GetIssuedAt()
+	if err != nil || iat == nil {
+		return "", invalidProof("missing iat")
+	}
+	maxAge := v.MaxAge
+	if maxAge <= 0 {
+		maxAge = defaultDPoPAge
+	}
+	now := time.Now()
+	if iat.After(now.Add(v.Leeway)) || now.Sub(iat.Time) > maxAge+v.Leeway {
+		return "", invalidProof("iat outside acceptable window")
+	}
+
+	if accessToken != "" {
+		if ath, _ := claims["ath"].(string); ath != accessTokenHash(accessToken) {
+			return "", invalidProof("ath mismatch")
+		}
+	}
+
+	if v.Replay != nil {
+		if err := v.Replay.Consume(jti, iat.Add(maxAge+v.Leeway)); err != nil {
+			return "", invalidProof("jti replayed")
+		}
+	}
+	return jwk.Thumbprint()
+}
+
+// checkDPoP 中间件调用：已绑定的 token 必须附带匹配的 proof
+func checkDPoP(v *DPoPVerifier, r *http.Request, tokenStr string, claims jwt.MapClaims) error {
+	jkt := boundThumbprint(claims)
+	if jkt == "" {
+		if v != nil && v.Required {
+			return ErrDPoPRequired
+		}
+		return nil
+	}
+	if v == nil {
+		// 未配置校验器时不能把已绑定的 token 当作 Bearer 接受
+		return ErrDPoPProofMissing
+	}
+	// RFC 9449 7.1：已绑定的 token 只能通过 Authorization: DPoP 传递，即使附带了 proof 也不接受 Bearer 或 Cookie
+	if t, ok := FromDPoPAuthHeader()(r); !ok || t != tokenStr {
+		return ErrDPoPSchemeRequired
+	}
+	got, err := v.Verify(r, tokenStr)
+	if err != nil {
+		return err
+	}
+	if got != jkt {
+		return ErrDPoPKeyMismatch
+	}
+	return nil
+}
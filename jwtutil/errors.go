@@ -0,0 +1,149 @@
+package jwtutil
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ParseToken 返回的错误，可使用 errors.Is 判断
+var (
+	ErrInvalidToken          = errors.New("invalid token")
+	ErrTokenMalformed        = errors.New("token malformed")
+	ErrInvalidSignature      = errors.New("token signature invalid")
+	ErrUnexpectedAlgorithm   = errors.New("unexpected signing algorithm")
+	ErrTokenExpired          = errors.New("token expired")
+	ErrTokenNotValidYet      = errors.New("token not valid yet")
+	ErrTokenUsedBeforeIssued = errors.New("token used before issued")
+	ErrInvalidIssuer         = errors.New("invalid issuer")
+	ErrInvalidAudience       = errors.New("invalid audience")
+	ErrMissingClaim          = errors.New("missing required claim")
+	ErrInvalidClaims         = errors.New("invalid claims")
+	ErrWrongTokenType        = errors.New("wrong token type")
+	ErrRevocationUnavailable = errors.New("revocation check unavailable")
+)
+
+// TokenError ParseToken 返回的错误类型，可用 errors.As 取得分类与底层原因
+// 所有 TokenError 都满足 errors.Is(err, ErrInvalidToken)
+type TokenError struct {
+	Kind error // 错误分类：ErrTokenExpired、ErrInvalidSignature、ErrTokenRevoked 等
+	Err  error // 底层原因，可能为 nil
+}
+
+func (e *TokenError) Error() string {
+	if e.Err == nil {
+		return e.Kind.Error()
+	}
+	return e.Kind.Error() + ": " + e.Err.Error()
+}
+
+func (e *TokenError) Unwrap() []error {
+	if e.Err == nil {
+		return []error{e.Kind}
+	}
+	return []error{e.Kind, e.Err}
+}
+
+func (e *TokenError) Is(target error) bool {
+	return target == ErrInvalidToken
+}
+
+// tokenKinds asTokenError 识别的分类，按顺序匹配
+var tokenKinds = []error{
+	ErrTokenMalformed,
+	ErrInvalidSignature,
+	ErrUnexpectedAlgorithm,
+	ErrTokenExpired,
+	ErrTokenNotValidYet,
+	ErrTokenUsedBeforeIssued,
+	ErrInvalidIssuer,
+	ErrInvalidAudience,
+	ErrMissingClaim,
+	ErrInvalidClaims,
+	ErrWrongTokenType,
+	ErrTokenRevoked,
+	ErrRevocationUnavailable,
+	ErrTokenDecryption,
+	ErrTokenNotEncrypted,
+}
+
+// asTokenError 把错误包装为 *TokenError，已是 *TokenError 时原样返回
+func asTokenError(err error) error {
+	var te *TokenError
+	if err == nil || errors.As(err, &te) {
+		return err
+	}
+	for _, kind := range tokenKinds {
+		if err == kind {
+			return &TokenError{Kind: kind}
+		}
+		if errors.Is(err, kind) {
+			return &TokenError{Kind: kind, Err: err}
+		}
+	}
+	if err == ErrInvalidToken {
+		return &TokenError{Kind: ErrInvalidToken}
+	}
+	return &TokenError{Kind: ErrInvalidToken, Err: err}
+}
+
+// translateError 把 jwt 库的错误转换为本包的 *TokenError
+func translateError(err error) error {
+	var kind error
+	switch {
+	case errors.Is(err, ErrUnexpectedAlgorithm):
+		return &TokenError{Kind: ErrUnexpectedAlgorithm}
+	case errors.Is(err, jwt.ErrTokenMalformed):
+		kind = ErrTokenMalformed
+	case errors.Is(err, jwt.ErrTokenSignatureInvalid):
+		kind = ErrInvalidSignature
+	case errors.Is(err, jwt.ErrTokenInvalidIssuer):
+		kind = ErrInvalidIssuer
+	case errors.Is(err, jwt.ErrTokenInvalidAudience):
+		kind = ErrInvalidAudience
+	case errors.Is(err, jwt.ErrTokenRequiredClaimMissing):
+		kind = ErrMissingClaim
+	case errors.Is(err, jwt.ErrTokenExpired):
+		return &TokenError{Kind: ErrTokenExpired}
+	case errors.Is(err, jwt.ErrTokenNotValidYet):
+		return &TokenError{Kind: ErrTokenNotValidYet}
+	case errors.Is(err, jwt.ErrTokenUsedBeforeIssued):
+		return &TokenError{Kind: ErrTokenUsedBeforeIssued}
+	case errors.Unwrap(err) == jwt.ErrTokenUnverifiable:
+		// 只包了一层 ErrTokenUnverifiable：alg 未注册或缺失，keyFunc 尚未执行
+		kind = ErrUnexpectedAlgorithm
+	default:
+		// keyFunc 的其它错误（如 kid 未找到、JWKS 拉取失败）保留为底层原因
+		kind = ErrInvalidToken
+	}
+	return &TokenError{Kind: kind, Err: err}
+}
+
+// AuthErrorCode 返回错误对应的 RFC 6750 error 码（DPoP proof 错误为 RFC 9449 的 invalid_dpop_proof）
+// 请求中没有任何凭据时按 RFC 6750 不返回错误码，此时返回空串；撤销列表故障不是 token 的问题，返回 temporarily_unavailable
+func AuthErrorCode(err error) string {
+	switch {
+	case err == nil, errors.Is(err, ErrNoToken):
+		return ""
+	case errors.Is(err, ErrRevocationUnavailable):
+		return "temporarily_unavailable"
+	case errors.Is(err, ErrDPoPProofMissing):
+		return "invalid_request"
+	case errors.Is(err, ErrInvalidDPoPProof):
+		return "invalid_dpop_proof"
+	}
+	return "invalid_token"
+}
+
+// authChallenge 生成 WWW-Authenticate 头的值
+func authChallenge(scheme, code, desc string) string {
+	if code == "" {
+		return scheme
+	}
+	v := fmt.Sprintf("%s error=%q", scheme, code)
+	if desc != "" {
+		v += fmt.Sprintf(", error_description=%q", desc)
+	}
+	return v
+}
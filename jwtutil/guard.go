@@ -0,0 +1,99 @@
+package jwtutil
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsPredicate 授权条件，返回 true 表示放行
+type ClaimsPredicate func(claims jwt.MapClaims) bool
+
+// claimStrings 把 claim 统一读成字符串列表：
+// 字符串按空格拆分（兼容 OAuth2 的 scope），数组逐项读取
+func claimStrings(claims jwt.MapClaims, name string) []string {
+	switch v := claims[name].(type) {
+	case string:
+		return strings.Fields(v)
+	case []string:
+		return v
+	case []interface{}:
+		out := make([]string, 0, len(v))
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				out = append(out, s)
+			}
+		}
+		return out
+	}
+	return nil
+}
+
+func containsAny(have []string, want ...string) bool {
+	for _, h := range have {
+		for _, w := range want {
+			if h == w {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// HasRole 要求 role 或 roles claim 中包含指定角色
+func HasRole(role string) ClaimsPredicate {
+	return func(claims jwt.MapClaims) bool {
+		return containsAny(claimStrings(claims, "role"), role) ||
+			containsAny(claimStrings(claims, "roles"), role)
+	}
+}
+
+// HasAnyScope 要求 scope（空格分隔）或 scp claim 中包含任意一个指定 scope
+func HasAnyScope(scopes ...string) ClaimsPredicate {
+	return func(claims jwt.MapClaims) bool {
+		return containsAny(claimStrings(claims, "scope"), scopes...) ||
+			containsAny(claimStrings(claims, "scp"), scopes...)
+	}
+}
+
+// Require 通用授权中间件，需放在 Middleware 之后
+// context 中没有 claims 时返回 401，不满足条件时返回 403
+func Require(pred ClaimsPredicate) func(http.Handler) http.Handler {
+	return requireWithCode(pred, "forbidden", "insufficient permissions", "")
+}
+
+// RequireRole 要求指定角色
+func RequireRole(role string) func(http.Handler) http.Handler {
+	return requireWithCode(HasRole(role), "forbidden", "role "+role+" required", "")
+}
+
+// RequireAnyScope 要求任意一个 scope
+func RequireAnyScope(scopes ...string) func(http.Handler) http.Handler {
+	scope := strings.Join(scopes, " ")
+	challenge := authChallenge("Bearer", "insufficient_scope", "") + fmt.Sprintf(", scope=%q", scope)
+	return requireWithCode(HasAnyScope(scopes...), "insufficient_scope", "one of scopes ["+scope+"] required", challenge)
+}
+
+// requireWithCode challenge 非空时在 403 响应中写入 WWW-Authenticate
+func requireWithCode(pred ClaimsPredicate, code, desc, challenge string) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			claims, ok := ClaimsFromContext(r.Context())
+			if !ok {
+				w.Header().Set("WWW-Authenticate", authChallenge("Bearer", "", ""))
+				writeJSONError(w, http.StatusUnauthorized, "unauthorized", ErrNoToken.Error())
+				return
+			}
+			if !pred(claims) {
+				if challenge != "" {
+					w.Header().Set("WWW-Authenticate", challenge)
+				}
+				writeJSONError(w, http.StatusForbidden, code, desc)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,363 @@
+package jwtutil
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha1"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// JWE 密钥管理算法，内容加密固定使用 A256GCM
+const (
+	JWEDirect     = "dir"          // 共享 32 字节对称密钥
+	JWERSAOAEP    = "RSA-OAEP"     // RSA-OAEP（SHA-1）
+	JWERSAOAEP256 = "RSA-OAEP-256" // RSA-OAEP（SHA-256）
+	JWEECDHES     = "ECDH-ES"      // ECDH 直接密钥协商（P-256/P-384/P-521/X25519）
+
+	jweEncA256GCM = "A256GCM"
+)
+
+var (
+	ErrTokenDecryption   = errors.New("token decryption failed")
+	ErrTokenNotEncrypted = errors.New("token is not encrypted")
+)
+
+// jweConfig 加密配置；只持有公钥时只能加密，只持有私钥时两者皆可
+type jweConfig struct {
+	alg    string
+	secret []byte           // dir
+	rsaPub *rsa.PublicKey   // RSA-OAEP 加密
+	rsaKey *rsa.PrivateKey  // RSA-OAEP 解密
+	ecPub  *ecdh.PublicKey  // ECDH-ES 加密
+	ecKey  *ecdh.PrivateKey // ECDH-ES 解密
+}
+
+type jweHeader struct {
+	Alg  string   `json:"alg"`
+	Enc  string   `json:"enc"`
+	Cty  string   `json:"cty,omitempty"`
+	Epk  *epkJWK  `json:"epk,omitempty"`
+	Zip  string   `json:"zip,omitempty"`
+	Crit []string `json:"crit,omitempty"`
+}
+
+// epkJWK ECDH-ES 临时公钥
+type epkJWK struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y,omitempty"`
+}
+
+// SetEncryption 开启 JWE 模式：GenerateToken 先签名再加密，ParseToken 先解密再验签
+// 开启后 ParseToken 只接受加密 token
+//
+//	dir:                  key 为 32 字节 []byte
+//	RSA-OAEP/RSA-OAEP-256: key 为 *rsa.PrivateKey（加解密）或 *rsa.PublicKey（只加密）
+//	ECDH-ES:              key 为 *ecdh.PrivateKey / *ecdsa.PrivateKey（加解密）或对应公钥（只加密）
+func (m *JWTManager) SetEncryption(alg string, key interface{}) error {
+	cfg := &jweConfig{alg: alg}
+	switch alg {
+	case JWEDirect:
+		secret, ok := key.([]byte)
+		if !ok || len(secret) != 32 {
+			return errors.New("jwe: dir with A256GCM requires a 32-byte key")
+		}
+		cfg.secret = secret
+	case JWERSAOAEP, JWERSAOAEP256:
+		switch k := key.(type) {
+		case *rsa.PrivateKey:
+			cfg.rsaKey, cfg.rsaPub = k, &k.PublicKey
+		case *rsa.PublicKey:
+			cfg.rsaPub = k
+		default:
+			return fmt.Errorf("jwe: %s requires an RSA key", alg)
+		}
+	case JWEECDHES:
+		switch k := key.(type) {
+		case *ecdh.PrivateKey:
+			cfg.ecKey, cfg.ecPub = k, k.PublicKey()
+		case *ecdh.PublicKey:
+			cfg.ecPub = k
+		case *ecdsa.PrivateKey:
+			ek, err := k.ECDH()
+			if err != nil {
+				return err
+			}
+			cfg.ecKey, cfg.ecPub = ek, ek.PublicKey()
+		case *ecdsa.PublicKey:
+			ek, err := k.ECDH()
+			if err != nil {
+				return err
+			}
+			cfg.ecPub = ek
+		default:
+			return errors.New("jwe: ECDH-ES requires an EC or X25519 key")
+		}
+		if _, err := curveName(cfg.ecPub.Curve()); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("jwe: unsupported alg %q", alg)
+	}
+	m.jwe = cfg
+	return nil
+}
+
+func curveName(c ecdh.Curve) (string, error) {
+	switch c {
+	case ecdh.P256():
+		return "P-256", nil
+	case ecdh.P384():
+		return "P-384", nil
+	case ecdh.P521():
+		return "P-521", nil
+	case ecdh.X25519():
+		return "X25519", nil
+	}
+	return "", errors.New("jwe: unsupported curve")
+}
+
+func curveByName(name string) (ecdh.Curve, error) {
+	switch name {
+	case "P-256":
+		return ecdh.P256(), nil
+	case "P-384":
+		return ecdh.P384(), nil
+	case "P-521":
+		return ecdh.P521(), nil
+	case "X25519":
+		return ecdh.X25519(), nil
+	}
+	return nil, fmt.Errorf("jwe: unsupported curve %q", name)
+}
+
+func (c *jweConfig) oaepHash() hash.Hash {
+	if c.alg == JWERSAOAEP256 {
+		return sha256.New()
+	}
+	return sha1.New()
+}
+
+// encrypt 把已签名的 JWS 加密为 compact JWE
+func (c *jweConfig) encrypt(jws string) (string, error) {
+	header := jweHeader{Alg: c.alg, Enc: jweEncA256GCM, Cty: "JWT"}
+	var cek, encryptedKey []byte
+
+	switch c.alg {
+	case JWEDirect:
+		cek = c.secret
+	case JWERSAOAEP, JWERSAOAEP256:
+		cek = make([]byte, 32)
+		if _, err := io.ReadFull(rand.Reader, cek); err != nil {
+			return "", err
+		}
+		var err error
+		encryptedKey, err = rsa.EncryptOAEP(c.oaepHash(), rand.Reader, c.rsaPub, cek, nil)
+		if err != nil {
+			return "", err
+		}
+	case JWEECDHES:
+		eph, err := c.ecPub.Curve().GenerateKey(rand.Reader)
+		if err != nil {
+			return "", err
+		}
+		z, err := eph.ECDH(c.ecPub)
+		if err != nil {
+			return "", err
+		}
+		header.Epk, err = newEPK(eph.PublicKey())
+		if err != nil {
+			return "", err
+		}
+		cek = concatKDF(z, jweEncA256GCM, 256)
+	}
+
+	hb, err := json.Marshal(header)
+	if err != nil {
+		return "", err
+	}
+	enc := base64.RawURLEncoding
+	protected := enc.EncodeToString(hb)
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	iv := make([]byte, gcm.NonceSize())
+	if _, err := io.ReadFull(rand.Reader, iv); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nil, iv, []byte(jws), []byte(protected))
+	ciphertext, tag := sealed[:len(sealed)-gcm.Overhead()], sealed[len(sealed)-gcm.Overhead():]
+
+	return strings.Join([]string{
+		protected,
+		enc.EncodeToString(encryptedKey),
+		enc.EncodeToString(iv),
+		enc.EncodeToString(ciphertext),
+		enc.EncodeToString(tag),
+	}, "."), nil
+}
+
+// decrypt 解密 compact JWE，返回内部的 JWS
+func (c *jweConfig) decrypt(token string) (string, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 5 {
+		return "", ErrTokenNotEncrypted
+	}
+	enc := base64.RawURLEncoding
+	var raw [5][]byte
+	for i, p := range parts {
+		b, err := enc.DecodeString(p)
+		if err != nil {
+			return "", ErrTokenDecryption
+		}
+		raw[i] = b
+	}
+
+	var header jweHeader
+	if err := json.Unmarshal(raw[0], &header); err != nil {
+		return "", ErrTokenDecryption
+	}
+	// 只接受配置的算法，防止算法替换
+	if header.Alg != c.alg || header.Enc != jweEncA256GCM || header.Zip != "" || len(header.Crit) > 0 {
+		return "", ErrTokenDecryption
+	}
+
+	var cek []byte
+	switch c.alg {
+	case JWEDirect:
+		if len(raw[1]) != 0 {
+			return "", ErrTokenDecryption
+		}
+		cek = c.secret
+	case JWERSAOAEP, JWERSAOAEP256:
+		if c.rsaKey == nil {
+			return "", errors.New("jwe: no decryption key")
+		}
+		k, err := rsa.DecryptOAEP(c.oaepHash(), nil, c.rsaKey, raw[1], nil)
+		if err != nil || len(k) != 32 {
+			return "", ErrTokenDecryption
+		}
+		cek = k
+	case JWEECDHES:
+		if c.ecKey == nil {
+			return "", errors.New("jwe: no decryption key")
+		}
+		if header.Epk == nil || len(raw[1]) != 0 {
+			return "", ErrTokenDecryption
+		}
+		epk, err := header.Epk.publicKey()
+		if err != nil || epk.Curve() != c.ecKey.Curve() {
+			return "", ErrTokenDecryption
+		}
+		z, err := c.ecKey.ECDH(epk)
+		if err != nil {
+			return "", ErrTokenDecryption
+		}
+		cek = concatKDF(z, jweEncA256GCM, 256)
+	}
+
+	gcm, err := newGCM(cek)
+	if err != nil {
+		return "", err
+	}
+	if len(raw[2]) != gcm.NonceSize() || len(raw[4]) != gcm.Overhead() {
+		return "", ErrTokenDecryption
+	}
+	plaintext, err := gcm.Open(nil, raw[2], append(raw[3], raw[4]...), []byte(parts[0]))
+	if err != nil {
+		return "", ErrTokenDecryption
+	}
+	return string(plaintext), nil
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
+
+func newEPK(pub *ecdh.PublicKey) (*epkJWK, error) {
+	crv, err := curveName(pub.Curve())
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding
+	b := pub.Bytes()
+	if crv == "X25519" {
+		return &epkJWK{Kty: "OKP", Crv: crv, X: enc.EncodeToString(b)}, nil
+	}
+	// 非压缩点：0x04 || X || Y
+	size := (len(b) - 1) / 2
+	return &epkJWK{
+		Kty: "EC",
+		Crv: crv,
+		X:   enc.EncodeToString(b[1 : 1+size]),
+		Y:   enc.EncodeToString(b[1+size:]),
+	}, nil
+}
+
+func (j *epkJWK) publicKey() (*ecdh.PublicKey, error) {
+	curve, err := curveByName(j.Crv)
+	if err != nil {
+		return nil, err
+	}
+	enc := base64.RawURLEncoding
+	x, err := enc.DecodeString(j.X)
+	if err != nil {
+		return nil, err
+	}
+	if j.Crv == "X25519" {
+		return curve.NewPublicKey(x)
+	}
+	y, err := enc.DecodeString(j.Y)
+	if err != nil {
+		return nil, err
+	}
+	if len(x) != len(y) {
+		return nil, errors.New("jwe: invalid epk")
+	}
+	return curve.NewPublicKey(append(append([]byte{4}, x...), y...))
+}
+
+// concatKDF RFC 7518 4.6.2，apu/apv 为空
+func concatKDF(z []byte, algID string, keyBits int) []byte {
+	lenPrefixed := func(b []byte) []byte {
+		out := make([]byte, 4+len(b))
+		binary.BigEndian.PutUint32(out, uint32(len(b)))
+		copy(out[4:], b)
+		return out
+	}
+	var otherInfo []byte
+	otherInfo = append(otherInfo, lenPrefixed([]byte(algID))...)
+	otherInfo = append(otherInfo, lenPrefixed(nil)...) // PartyUInfo
+	otherInfo = append(otherInfo, lenPrefixed(nil)...) // PartyVInfo
+	otherInfo = binary.BigEndian.AppendUint32(otherInfo, uint32(keyBits))
+
+	keyLen := keyBits / 8
+	var out []byte
+	for counter := uint32(1); len(out) < keyLen; counter++ {
+		h := sha256.New()
+		binary.Write(h, binary.BigEndian, counter)
+		h.Write(z)
+		h.Write(otherInfo)
+		out = h.Sum(out)
+	}
+	return out[:keyLen]
+}
@@ -0,0 +1,350 @@
+package jwtutil
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// KeySet 按 kid 查找校验密钥，KeyRing 与 JWKSKeySet 都实现了该接口
+type KeySet interface {
+	Lookup(kid string) (*Key, error)
+}
+
+// JWK 单个 JSON Web Key（只包含公钥参数）
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid,omitempty"`
+	Alg string `json:"alg,omitempty"`
+	Use string `json:"use,omitempty"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+	Y   string `json:"y,omitempty"`
+}
+
+// JWKS JSON Web Key Set
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// NewJWK 把公钥编码为 JWK
+func NewJWK(kid string, method jwt.SigningMethod, pub crypto.PublicKey) (JWK, error) {
+	enc := base64.RawURLEncoding
+	jwk := JWK{Kid: kid, Alg: method.Alg(), Use: "sig"}
+	switch k := pub.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = enc.EncodeToString(k.N.Bytes())
+		jwk.E = enc.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case *ecdsa.PublicKey:
+		size := (k.Curve.Params().BitSize + 7) / 8
+		jwk.Kty = "EC"
+		jwk.Crv = k.Curve.Params().Name
+		jwk.X = enc.EncodeToString(k.X.FillBytes(make([]byte, size)))
+		jwk.Y = enc.EncodeToString(k.Y.FillBytes(make([]byte, size)))
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = enc.EncodeToString(k)
+	default:
+		return JWK{}, fmt.Errorf("unsupported public key type %T", pub)
+	}
+	return jwk, nil
+}
+
+// Key 把 JWK 解码为 Key，未声明 alg 时按 kty/crv 推断
+func (j JWK) Key() (*Key, error) {
+	enc := base64.RawURLEncoding
+	var pub crypto.PublicKey
+	alg := j.Alg
+	switch j.Kty {
+	case "RSA":
+		n, err := enc.DecodeString(j.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := enc.DecodeString(j.E)
+		if err != nil {
+			return nil, err
+		}
+		pub = &rsa.PublicKey{N: new(big.Int).SetBytes(n), E: int(new(big.Int).SetBytes(e).Int64())}
+		if alg == "" {
+			alg = "RS256"
+		}
+	case "EC":
+		var curve elliptic.Curve
+		switch j.Crv {
+		case "P-256":
+			curve, alg = elliptic.P256(), defaultString(alg, "ES256")
+		case "P-384":
+			curve, alg = elliptic.P384(), defaultString(alg, "ES384")
+		case "P-521":
+			curve, alg = elliptic.P521(), defaultString(alg, "ES512")
+		default:
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := enc.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := enc.DecodeString(j.Y)
+		if err != nil {
+			return nil, err
+		}
+		pub = &ecdsa.PublicKey{Curve: curve, X: new(big.Int).SetBytes(x), Y: new(big.Int).SetBytes(y)}
+	case "OKP":
+		if j.Crv != "Ed25519" {
+			return nil, fmt.Errorf("unsupported curve %q", j.Crv)
+		}
+		x, err := enc.DecodeString(j.X)
+		if err != nil {
+			return nil, err
+		}
+		if len(x) != ed25519.PublicKeySize {
+			return nil, errors.New("invalid Ed25519 key size")
+		}
+		pub, alg = ed25519.PublicKey(x), defaultString(alg, "EdDSA")
+	default:
+		return nil, fmt.Errorf("unsupported kty %q", j.Kty)
+	}
+
+	method := jwt.GetSigningMethod(alg)
+	if method == nil {
+		return nil, fmt.Errorf("unsupported alg %q", alg)
+	}
+	if err := checkKey(method, pub); err != nil {
+		return nil, err
+	}
+	return &Key{ID: j.Kid, Method: method, VerifyKey: pub}, nil
+}
+
+func defaultString(s, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
+
+// JWKS 导出密钥环中所有非对称公钥（HMAC 密钥不会公开）
+func (r *KeyRing) JWKS() JWKS {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	set := JWKS{Keys: []JWK{}}
+	for _, id := range r.sortedIDsLocked() {
+		k := r.keys[id]
+		if _, ok := k.Method.(*jwt.SigningMethodHMAC); ok {
+			continue
+		}
+		jwk, err := NewJWK(k.ID, k.Method, k.VerifyKey)
+		if err != nil {
+			continue
+		}
+		set.Keys = append(set.Keys, jwk)
+	}
+	return set
+}
+
+// JWKSHandler 以 JWKS 格式对外发布密钥环中的公钥
+func JWKSHandler(ring *KeyRing, maxAge time.Duration) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		w.Header().Set("Content-Type", "application/json")
+		if maxAge > 0 {
+			w.Header().Set("Cache-Control", fmt.Sprintf("public, max-age=%d", int(maxAge.Seconds())))
+		}
+		json.NewEncoder(w).Encode(ring.JWKS())
+	})
+}
+
+// ---------------------------
+// JWKS 校验端
+// ---------------------------
+
+// JWKSKeySet 从远端 JWKS URL 拉取并缓存公钥
+// 缓存超过 RefreshInterval 时在后台刷新并先返回旧公钥，遇到未知 kid 时立即重新拉取
+// 两次拉取间隔不小于 MinRefetchInterval，连续失败时按指数退避，最长不超过 RefreshInterval
+type JWKSKeySet struct {
+	URL                string
+	Client             *http.Client
+	RefreshInterval    time.Duration // 缓存有效期
+	MinRefetchInterval time.Duration // 两次拉取的最小间隔，防止被恶意 kid 刷爆
+
+	mu          sync.RWMutex
+	keys        map[string]*Key
+	fetchedAt   time.Time  // 最近一次成功拉取
+	attemptedAt time.Time  // 最近一次拉取（无论成败）
+	failures    int        // 连续失败次数
+	fetchMu     sync.Mutex // 串行化拉取
+}
+
+func NewJWKSKeySet(url string) *JWKSKeySet {
+	return &JWKSKeySet{
+		URL:                url,
+		Client:             &http.Client{Timeout: 10 * time.Second},
+		RefreshInterval:    time.Hour,
+		MinRefetchInterval: 10 * time.Second,
+		keys:               make(map[string]*Key),
+	}
+}
+
+// NewJWKSVerifier 基于远端 JWKS 创建只校验的管理器
+func NewJWKSVerifier(url string, cookieName string) (*JWTManager, *JWKSKeySet) {
+	set := NewJWKSKeySet(url)
+	return NewJWTVerifierWithKeySet(set, cookieName), set
+}
+
+// Refresh 立即拉取 JWKS 并替换缓存
+func (s *JWKSKeySet) Refresh(ctx context.Context) error {
+	s.fetchMu.Lock()
+	defer s.fetchMu.Unlock()
+	return s.fetchRecorded(ctx)
+}
+
+// fetchLocked 假设已经持有 fetchMu
+func (s *JWKSKeySet) fetchLocked(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.URL, nil)
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := s.Client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("jwks fetch failed: %s", resp.Status)
+	}
+
+	var set JWKS
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return err
+	}
+	keys := make(map[string]*Key, len(set.Keys))
+	for _, jwk := range set.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		k, err := jwk.Key()
+		if err != nil {
+			// 跳过不支持的密钥，不影响其他密钥
+			continue
+		}
+		keys[k.ID] = k
+	}
+
+	s.mu.Lock()
+	s.keys = keys
+	s.fetchedAt = time.Now()
+	s.mu.Unlock()
+	return nil
+}
+
+// fetchRecorded 拉取并记录本次尝试，假设已经持有 fetchMu
+func (s *JWKSKeySet) fetchRecorded(ctx context.Context) error {
+	err := s.fetchLocked(ctx)
+	s.mu.Lock()
+	s.attemptedAt = time.Now()
+	if err != nil {
+		s.failures++
+	} else {
+		s.failures = 0
+	}
+	s.mu.Unlock()
+	return err
+}
+
+func (s *JWKSKeySet) cached(kid string) (*Key, time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	k, ok := s.keys[kid]
+	return k, s.fetchedAt, ok
+}
+
+// fetchAllowed 距上次拉取是否已超过最小间隔（失败后按指数退避）
+func (s *JWKSKeySet) fetchAllowed() (time.Time, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if s.attemptedAt.IsZero() {
+		return s.attemptedAt, true
+	}
+	wait, limit := s.MinRefetchInterval, max(s.RefreshInterval, s.MinRefetchInterval)
+	for i := 1; i < s.failures && wait > 0 && wait < limit; i++ {
+		wait *= 2
+	}
+	wait = min(wait, limit)
+	return s.attemptedAt, time.Since(s.attemptedAt) >= wait
+}
+
+// Lookup 按 kid 查找公钥，必要时刷新缓存
+func (s *JWKSKeySet) Lookup(kid string) (*Key, error) {
+	k, fetchedAt, ok := s.cached(kid)
+	stale := fetchedAt.IsZero() || time.Since(fetchedAt) > s.RefreshInterval
+	if ok && !stale {
+		return k, nil
+	}
+	attemptedAt, allowed := s.fetchAllowed()
+	if ok {
+		// 缓存过期但仍有该 kid：直接返回旧公钥，由后台刷新
+		if allowed && s.fetchMu.TryLock() {
+			go func() {
+				defer s.fetchMu.Unlock()
+				s.fetchRecorded(context.Background())
+			}()
+		}
+		return k, nil
+	}
+	if !allowed {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+
+	s.fetchMu.Lock()
+	// 等锁期间其他协程可能已经拉取过
+	if latest, _ := s.fetchAllowed(); latest.Equal(attemptedAt) {
+		if err := s.fetchRecorded(context.Background()); err != nil {
+			s.fetchMu.Unlock()
+			return nil, err
+		}
+	}
+	s.fetchMu.Unlock()
+
+	if k, _, ok := s.cached(kid); ok {
+		return k, nil
+	}
+	return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+}
+
+// StartAutoRefresh 启动后台周期刷新（可选，Lookup 本身会惰性刷新）
+func (s *JWKSKeySet) StartAutoRefresh(interval time.Duration, stop <-chan struct{}) {
+	go func() {
+		t := time.NewTicker(interval)
+		defer t.Stop()
+		for {
+			select {
+			case <-t.C:
+				s.Refresh(context.Background())
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
@@ -1,17 +1,45 @@
 package jwtutil
 
 import (
+	"crypto"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
 	"errors"
+	"fmt"
+	"math"
 	"net/http"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
 )
 
+var ErrNoSigningKey = errors.New("signing key not configured")
+
 type JWTManager struct {
 	Secret        []byte
 	TokenDuration time.Duration
 	CookieName    string
+
+	// 注册 claim 校验，零值表示不校验
+	Issuer         string        // 期望的 iss，GenerateToken 自动写入
+	Audience       []string      // 接受的 aud（满足任意一个即可），GenerateToken 自动写入
+	Leeway         time.Duration // 校验 exp/nbf/iat 时容忍的时钟偏差
+	RequiredClaims []string      // 必须存在的 claim
+	SubjectClaim   string        // GenerateToken 缺少 sub 时从该 claim 复制，例如 "user_id"
+
+	method    jwt.SigningMethod // 签名算法，为空时默认 HS256
+	signKey   interface{}       // 签名密钥（HMAC 为 Secret，非对称为私钥，只校验时为 nil）
+	verifyKey interface{}       // 校验密钥（HMAC 为 Secret，非对称为公钥）
+	keys      *KeyRing          // 密钥环，设置后使用活动密钥签发
+	keySet    KeySet            // 按 kid 选择校验密钥（密钥环或远端 JWKS）
+
+	revocation RevocationStore // 撤销列表，为 nil 时不检查
+	jwe        *jweConfig      // JWE 加密配置，为 nil 时输出普通 JWS
+	clock      clockutil.Clock // 时间来源，为 nil 时使用系统时钟
+
+	cookiePolicy // Cookie 属性（SetCookieOptions）
 }
 
 func NewJWTManager(secret string, duration time.Duration, cookieName string) *JWTManager {
@@ -22,40 +50,357 @@ func NewJWTManager(secret string, duration time.Duration, cookieName string) *JW
 	}
 }
 
-// 生成 JWT
+// NewJWTManagerWithKey 使用非对称私钥创建管理器，既可签发也可校验
+// 支持 RS256/PS256 等 RSA 算法（*rsa.PrivateKey）、ES256/ES384（*ecdsa.PrivateKey）以及 EdDSA（任意 crypto.Signer）
+// privateKey 为 nil 时返回 ErrNilKey，类型与算法不符时返回 ErrKeyMismatch
+func NewJWTManagerWithKey(method jwt.SigningMethod, privateKey crypto.Signer, duration time.Duration, cookieName string) (*JWTManager, error) {
+	if err := checkSigner(method, privateKey); err != nil {
+		return nil, err
+	}
+	return &JWTManager{
+		TokenDuration: duration,
+		CookieName:    cookieName,
+		method:        method,
+		signKey:       privateKey,
+		verifyKey:     privateKey.Public(),
+	}, nil
+}
+
+// NewJWTVerifier 使用公钥创建只校验的管理器，GenerateToken 会返回 ErrNoSigningKey
+func NewJWTVerifier(method jwt.SigningMethod, publicKey crypto.PublicKey, cookieName string) (*JWTManager, error) {
+	if err := checkKey(method, publicKey); err != nil {
+		return nil, err
+	}
+	return &JWTManager{
+		CookieName: cookieName,
+		method:     method,
+		verifyKey:  publicKey,
+	}, nil
+}
+
+// NewJWTManagerWithKeyRing 使用密钥环创建管理器
+// 签发时使用活动密钥并写入 kid 头部，解析时按 kid 选择校验密钥
+func NewJWTManagerWithKeyRing(ring *KeyRing, duration time.Duration, cookieName string) *JWTManager {
+	return &JWTManager{
+		TokenDuration: duration,
+		CookieName:    cookieName,
+		keys:          ring,
+		keySet:        ring,
+	}
+}
+
+// NewJWTVerifierWithKeySet 使用任意 KeySet（如 JWKSKeySet）创建只校验的管理器
+func NewJWTVerifierWithKeySet(set KeySet, cookieName string) *JWTManager {
+	return &JWTManager{
+		CookieName: cookieName,
+		keySet:     set,
+	}
+}
+
+// KeyRing 返回密钥环（未使用密钥环时为 nil）
+func (m *JWTManager) KeyRing() *KeyRing {
+	return m.keys
+}
+
+// SetRevocationStore 设置撤销列表，ParseToken 会拒绝已撤销的 token
+func (m *JWTManager) SetRevocationStore(store RevocationStore) {
+	m.revocation = store
+}
+
+// SetClock 设置时间来源，签发与校验 exp/nbf/iat 都以它为准，测试中可传入 FakeClock
+func (m *JWTManager) SetClock(c clockutil.Clock) {
+	m.clock = c
+}
+
+func (m *JWTManager) now() time.Time {
+	return clockutil.OrReal(m.clock).Now()
+}
+
+// Method 返回当前使用的签名算法
+func (m *JWTManager) Method() jwt.SigningMethod {
+	if m.method == nil {
+		return jwt.SigningMethodHS256
+	}
+	return m.method
+}
+
+func (m *JWTManager) signingKey() interface{} {
+	if m.method == nil {
+		return m.Secret
+	}
+	return m.signKey
+}
+
+func (m *JWTManager) verificationKey() interface{} {
+	if m.method == nil {
+		return m.Secret
+	}
+	return m.verifyKey
+}
+
+// newSignedToken 按当前配置选择算法与密钥，密钥环模式下写入 kid
+func (m *JWTManager) newSignedToken(claims jwt.Claims) (*jwt.Token, interface{}, error) {
+	if m.keys != nil {
+		k, err := m.keys.Active()
+		if err != nil {
+			return nil, nil, err
+		}
+		token := jwt.NewWithClaims(k.Method, claims)
+		token.Header["kid"] = k.ID
+		return token, k.SignKey, nil
+	}
+	key := m.signingKey()
+	if key == nil || m.keySet != nil {
+		return nil, nil, ErrNoSigningKey
+	}
+	return jwt.NewWithClaims(m.Method(), claims), key, nil
+}
+
+// keyFunc 解析时选择校验密钥，alg 头部必须与密钥的算法一致
+func (m *JWTManager) keyFunc(t *jwt.Token) (interface{}, error) {
+	if m.keySet == nil {
+		if t.Method.Alg() != m.Method().Alg() {
+			return nil, ErrUnexpectedAlgorithm
+		}
+		return m.verificationKey(), nil
+	}
+	kid, _ := t.Header["kid"].(string)
+	k, err := m.keySet.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if t.Method.Alg() != k.Method.Alg() {
+		return nil, ErrUnexpectedAlgorithm
+	}
+	return k.VerifyKey, nil
+}
+
+// newTokenID 生成随机的 token ID（jti）
+func newTokenID() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		panic(err)
+	}
+	return hex.EncodeToString(b)
+}
+
+// issuedAtValue iat 精确到微秒，RevokeSubject 在同一秒内之后签发的 token 不会被误撤销
+func issuedAtValue(t time.Time) float64 {
+	return float64(t.UnixMicro()) / 1e6
+}
+
+// issuedAt 按微秒精度读取 iat（jwt 库的 NumericDate 默认截断到秒）
+func issuedAt(claims jwt.MapClaims) time.Time {
+	var f float64
+	switch v := claims["iat"].(type) {
+	case float64:
+		f = v
+	case json.Number:
+		f, _ = v.Float64()
+	default:
+		return time.Time{}
+	}
+	return time.UnixMicro(int64(math.Round(f * 1e6)))
+}
+
+// fillRegisteredClaims 写入 exp，并在缺失时补上 jti/iat/nbf/iss/aud/sub
+func (m *JWTManager) fillRegisteredClaims(claims jwt.MapClaims) {
+	now := m.now()
+	claims["exp"] = now.Add(m.TokenDuration).Unix()
+	setDefault := func(name string, v interface{}) {
+		if _, ok := claims[name]; !ok {
+			claims[name] = v
+		}
+	}
+	setDefault("jti", newTokenID())
+	setDefault("iat", issuedAtValue(now))
+	setDefault("nbf", now.Unix())
+	if m.Issuer != "" {
+		setDefault("iss", m.Issuer)
+	}
+	switch len(m.Audience) {
+	case 0:
+	case 1:
+		setDefault("aud", m.Audience[0])
+	default:
+		setDefault("aud", m.Audience)
+	}
+	if m.SubjectClaim != "" {
+		if v, ok := claims[m.SubjectClaim]; ok {
+			setDefault("sub", fmt.Sprint(v))
+		}
+	}
+}
+
+// parserOptions 根据配置生成 jwt 解析选项
+func (m *JWTManager) parserOptions() []jwt.ParserOption {
+	opts := []jwt.ParserOption{jwt.WithIssuedAt(), jwt.WithTimeFunc(m.now)}
+	if m.Leeway > 0 {
+		opts = append(opts, jwt.WithLeeway(m.Leeway))
+	}
+	if m.Issuer != "" {
+		opts = append(opts, jwt.WithIssuer(m.Issuer))
+	}
+	if len(m.Audience) > 0 {
+		opts = append(opts, jwt.WithAudience(m.Audience...))
+	}
+	return opts
+}
+
+func (m *JWTManager) checkRequiredClaims(claims jwt.MapClaims) error {
+	for _, name := range m.RequiredClaims {
+		if _, ok := claims[name]; !ok {
+			return &TokenError{Kind: ErrMissingClaim, Err: errors.New(name)}
+		}
+	}
+	return nil
+}
+
+// 生成 JWT，自动写入 exp，并在缺失时补上 jti/iat/nbf 以及配置的 iss/aud/sub
 func (m *JWTManager) GenerateToken(claims jwt.MapClaims) (string, error) {
-	claims["exp"] = time.Now().Add(m.TokenDuration).Unix()
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(m.Secret)
+	m.fillRegisteredClaims(claims)
+	return m.sign(claims)
+}
+
+// sign 签名，开启 JWE 时再加密
+func (m *JWTManager) sign(claims jwt.MapClaims) (string, error) {
+	token, key, err := m.newSignedToken(claims)
+	if err != nil {
+		return "", err
+	}
+	signed, err := token.SignedString(key)
+	if err != nil || m.jwe == nil {
+		return signed, err
+	}
+	return m.jwe.encrypt(signed)
 }
 
-// 解析 JWT
+// 解析 JWT，alg 头部必须与配置的算法一致
+// 校验失败时返回 *TokenError，可用 errors.Is 判断 ErrTokenExpired、ErrInvalidSignature 等分类
+// refresh token 与一次性 token 不能当作会话 token 使用，返回 ErrWrongTokenType
 func (m *JWTManager) ParseToken(tokenStr string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenStr, func(t *jwt.Token) (interface{}, error) {
-		return m.Secret, nil
-	})
-	if err != nil || !token.Valid {
-		return nil, errors.New("invalid token")
+	claims, err := m.parseClaims(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if err := checkSessionToken(claims); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// parseClaims 同 ParseToken，但不检查 token 用途，供 refresh token 与一次性 token 使用
+func (m *JWTManager) parseClaims(tokenStr string) (jwt.MapClaims, error) {
+	token, err := m.parse(tokenStr)
+	if err != nil {
+		return nil, err
 	}
 	return token.Claims.(jwt.MapClaims), nil
 }
 
-// 写 HttpOnly Cookie
+// checkSessionToken 拒绝专用 token 出现在会话/access token 的位置
+func checkSessionToken(claims jwt.MapClaims) error {
+	if claims["typ"] == refreshTokenType {
+		return &TokenError{Kind: ErrWrongTokenType, Err: errors.New("refresh token")}
+	}
+	if _, ok := claims[PurposeClaim]; ok {
+		return &TokenError{Kind: ErrWrongTokenType, Err: errors.New("one-time token")}
+	}
+	return nil
+}
+
+// parse 完成签名、注册 claim、必需 claim 与撤销校验，返回解析后的 token
+func (m *JWTManager) parse(tokenStr string) (*jwt.Token, error) {
+	if m.jwe != nil {
+		jws, err := m.jwe.decrypt(tokenStr)
+		if err != nil {
+			return nil, asTokenError(err)
+		}
+		tokenStr = jws
+	}
+	token, err := jwt.Parse(tokenStr, m.keyFunc, m.parserOptions()...)
+	if err != nil {
+		return nil, translateError(err)
+	}
+	if !token.Valid {
+		return nil, asTokenError(ErrInvalidToken)
+	}
+	claims := token.Claims.(jwt.MapClaims)
+	if err := m.checkRequiredClaims(claims); err != nil {
+		return nil, err
+	}
+	if err := m.checkRevoked(claims); err != nil {
+		return nil, err
+	}
+	return token, nil
+}
+
+func (m *JWTManager) checkRevoked(claims jwt.MapClaims) error {
+	if m.revocation == nil {
+		return nil
+	}
+	jti, _ := claims["jti"].(string)
+	sub, _ := claims.GetSubject()
+	revoked, err := m.revocation.IsRevoked(jti, sub, issuedAt(claims))
+	if err != nil {
+		return &TokenError{Kind: ErrRevocationUnavailable, Err: err}
+	}
+	// 续签出的 token 同时按会话标识检查
+	if sid := sessionID(claims); !revoked && sid != jti {
+		if revoked, err = m.revocation.IsRevoked(sid, "", time.Time{}); err != nil {
+			return &TokenError{Kind: ErrRevocationUnavailable, Err: err}
+		}
+	}
+	if revoked {
+		return &TokenError{Kind: ErrTokenRevoked}
+	}
+	return nil
+}
+
+// RevokeToken 撤销一个有效 token（例如登出），同一会话中续签前后的 token 一并失效
+// 记录至少保留 TokenDuration：此前续签出的 token 都会在这之前过期，之后的续签会因撤销被拒绝
+func (m *JWTManager) RevokeToken(tokenStr string) error {
+	if m.revocation == nil {
+		return errors.New("revocation store not configured")
+	}
+	claims, err := m.ParseToken(tokenStr)
+	if err != nil {
+		return err
+	}
+	sid := sessionID(claims)
+	if sid == "" {
+		return errors.New("token has no jti")
+	}
+	exp, err := claims.GetExpirationTime()
+	if err != nil || exp == nil {
+		return errors.New("token has no exp")
+	}
+	expireAt := exp.Time
+	if limit := m.now().Add(m.TokenDuration); limit.After(expireAt) {
+		expireAt = limit
+	}
+	return m.revocation.RevokeToken(sid, expireAt)
+}
+
+// RevokeSubject 撤销 subject（sub claim）在 before 及之前签发的全部 token
+// 例如全端登出、强制下线被盗账号：RevokeSubject(userID, time.Now())
+// before 按 iat 的精度截断到微秒
+func (m *JWTManager) RevokeSubject(subject string, before time.Time) error {
+	if m.revocation == nil {
+		return errors.New("revocation store not configured")
+	}
+	before = time.UnixMicro(before.UnixMicro())
+	return m.revocation.RevokeSubject(subject, before, before.Add(m.TokenDuration))
+}
+
+// 写 HttpOnly Cookie，属性由 SetCookieOptions 配置
 func (m *JWTManager) SetTokenCookie(w http.ResponseWriter, token string) {
-	http.SetCookie(w, &http.Cookie{
-		Name:     m.CookieName,
-		Value:    token,
-		HttpOnly: true,
-		Path:     "/",
-		MaxAge:   int(m.TokenDuration.Seconds()),
-		Secure:   false, // 生产环境改为 true (https)
-		SameSite: http.SameSiteLaxMode,
-	})
+	http.SetCookie(w, m.newCookie(token, int(m.TokenDuration.Seconds())))
 }
 
 // 从请求读取 Cookie 中的 JWT
 func (m *JWTManager) ReadTokenFromCookie(r *http.Request) (string, error) {
-	c, err := r.Cookie(m.CookieName)
+	c, err := r.Cookie(m.TokenCookieName())
 	if err != nil {
 		return "", err
 	}
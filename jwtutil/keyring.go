@@ -0,0 +1,153 @@
+package jwtutil
+
+import (
+	"crypto"
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyNotFound  = errors.New("key not found")
+	ErrNoActiveKey  = errors.New("no active signing key")
+	ErrKeyIsActive  = errors.New("cannot retire the active key")
+	ErrKeyNotSigner = errors.New("key cannot sign")
+)
+
+// Key 密钥环中的一把密钥
+type Key struct {
+	ID        string            // kid
+	Method    jwt.SigningMethod // 该密钥对应的算法
+	SignKey   interface{}       // 签名密钥，为 nil 表示只用于校验
+	VerifyKey interface{}       // 校验密钥
+}
+
+// CanSign 是否可用于签发
+func (k *Key) CanSign() bool {
+	return k.SignKey != nil
+}
+
+// KeyRing 密钥环：一把活动签名密钥 + 多把校验密钥，支持运行时轮换
+type KeyRing struct {
+	mu     sync.RWMutex
+	keys   map[string]*Key
+	active string
+}
+
+func NewKeyRing() *KeyRing {
+	return &KeyRing{keys: make(map[string]*Key)}
+}
+
+// AddKey 添加密钥（已存在同 kid 时覆盖）
+// HMAC 算法传 []byte；非对称算法传私钥（可签发）或公钥（只校验）
+func (r *KeyRing) AddKey(kid string, method jwt.SigningMethod, key interface{}) error {
+	if kid == "" {
+		return errors.New("kid is empty")
+	}
+	k, err := newKey(kid, method, key)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if kid == r.active && !k.CanSign() {
+		return fmt.Errorf("%w: %s is active", ErrKeyNotSigner, kid)
+	}
+	r.keys[kid] = k
+	return nil
+}
+
+func newKey(kid string, method jwt.SigningMethod, key interface{}) (*Key, error) {
+	if method == nil {
+		return nil, errors.New("signing method is nil")
+	}
+	if _, ok := method.(*jwt.SigningMethodHMAC); ok {
+		secret, ok := key.([]byte)
+		if !ok || len(secret) == 0 {
+			return nil, fmt.Errorf("%w: %s requires a non-empty []byte secret", ErrKeyMismatch, method.Alg())
+		}
+		return &Key{ID: kid, Method: method, SignKey: secret, VerifyKey: secret}, nil
+	}
+
+	if signer, ok := key.(crypto.Signer); ok {
+		if err := checkSigner(method, signer); err != nil {
+			return nil, err
+		}
+		return &Key{ID: kid, Method: method, SignKey: signer, VerifyKey: signer.Public()}, nil
+	}
+	if err := checkKey(method, key); err != nil {
+		return nil, err
+	}
+	return &Key{ID: kid, Method: method, VerifyKey: key}, nil
+}
+
+// SetActive 把某把密钥提升为活动签名密钥，之后签发的 token 都使用它
+func (r *KeyRing) SetActive(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	k, ok := r.keys[kid]
+	if !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	if !k.CanSign() {
+		return fmt.Errorf("%w: %s", ErrKeyNotSigner, kid)
+	}
+	r.active = kid
+	return nil
+}
+
+// Retire 移除一把密钥，用它签发的 token 将无法再通过校验
+func (r *KeyRing) Retire(kid string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if _, ok := r.keys[kid]; !ok {
+		return fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	if kid == r.active {
+		return ErrKeyIsActive
+	}
+	delete(r.keys, kid)
+	return nil
+}
+
+// Active 返回活动签名密钥
+func (r *KeyRing) Active() (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if r.active == "" {
+		return nil, ErrNoActiveKey
+	}
+	return r.keys[r.active], nil
+}
+
+// Lookup 按 kid 查找密钥
+func (r *KeyRing) Lookup(kid string) (*Key, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	k, ok := r.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrKeyNotFound, kid)
+	}
+	return k, nil
+}
+
+// KeyIDs 返回所有 kid（已排序）
+func (r *KeyRing) KeyIDs() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.sortedIDsLocked()
+}
+
+// sortedIDsLocked 假设已经持有 mu
+func (r *KeyRing) sortedIDsLocked() []string {
+	ids := make([]string, 0, len(r.keys))
+	for id := range r.keys {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	return ids
+}
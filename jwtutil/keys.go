@@ -0,0 +1,85 @@
+package jwtutil
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrKeyMismatch = errors.New("key does not match signing method")
+	ErrNilKey      = errors.New("key is nil")
+)
+
+// checkSigner 校验私钥不为 nil（包括 typed nil），且是 golang-jwt 对应算法能直接使用的类型：
+// RS/PS 需要 *rsa.PrivateKey，ES 需要 *ecdsa.PrivateKey；KMS/HSM 等其它 crypto.Signer 只支持 EdDSA
+func checkSigner(method jwt.SigningMethod, key crypto.Signer) error {
+	if key == nil {
+		return ErrNilKey
+	}
+	if v := reflect.ValueOf(key); (v.Kind() == reflect.Pointer || v.Kind() == reflect.Slice) && v.IsNil() {
+		return ErrNilKey
+	}
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := key.(*rsa.PrivateKey); !ok {
+			return fmt.Errorf("%w: %s requires an *rsa.PrivateKey, got %T", ErrKeyMismatch, method.Alg(), key)
+		}
+	case *jwt.SigningMethodECDSA:
+		if _, ok := key.(*ecdsa.PrivateKey); !ok {
+			return fmt.Errorf("%w: %s requires an *ecdsa.PrivateKey, got %T", ErrKeyMismatch, method.Alg(), key)
+		}
+	case *jwt.SigningMethodEd25519:
+		if k, ok := key.(ed25519.PrivateKey); ok && len(k) != ed25519.PrivateKeySize {
+			return fmt.Errorf("%w: invalid Ed25519 private key length", ErrKeyMismatch)
+		}
+	}
+	return checkKey(method, key.Public())
+}
+
+// checkKey 校验公钥类型是否与算法匹配，避免把 RSA 公钥配给 ES256 之类的错误配置
+func checkKey(method jwt.SigningMethod, pub crypto.PublicKey) error {
+	if method == nil {
+		return errors.New("signing method is nil")
+	}
+	switch method.(type) {
+	case *jwt.SigningMethodRSA, *jwt.SigningMethodRSAPSS:
+		if _, ok := pub.(*rsa.PublicKey); !ok {
+			return fmt.Errorf("%w: %s requires an RSA key", ErrKeyMismatch, method.Alg())
+		}
+	case *jwt.SigningMethodECDSA:
+		k, ok := pub.(*ecdsa.PublicKey)
+		if !ok {
+			return fmt.Errorf("%w: %s requires an ECDSA key", ErrKeyMismatch, method.Alg())
+		}
+		if want := curveFor(method.Alg()); want != nil && k.Curve != want {
+			return fmt.Errorf("%w: %s requires curve %s", ErrKeyMismatch, method.Alg(), want.Params().Name)
+		}
+	case *jwt.SigningMethodEd25519:
+		if _, ok := pub.(ed25519.PublicKey); !ok {
+			return fmt.Errorf("%w: %s requires an Ed25519 key", ErrKeyMismatch, method.Alg())
+		}
+	default:
+		return fmt.Errorf("%w: unsupported asymmetric method %s", ErrKeyMismatch, method.Alg())
+	}
+	return nil
+}
+
+func curveFor(alg string) elliptic.Curve {
+	switch alg {
+	case "ES256":
+		return elliptic.P256()
+	case "ES384":
+		return elliptic.P384()
+	case "ES512":
+		return elliptic.P521()
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package jwtutil
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrNoToken = errors.New("no token")
+
+// TokenExtractor 从请求中提取 token，找不到时返回 false
+type TokenExtractor func(r *http.Request) (string, bool)
+
+// FromCookie 从指定 Cookie 读取 token
+func FromCookie(name string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		c, err := r.Cookie(name)
+		if err != nil || c.Value == "" {
+			return "", false
+		}
+		return c.Value, true
+	}
+}
+
+// FromAuthHeader 从 Authorization: Bearer <token> 读取 token
+func FromAuthHeader() TokenExtractor {
+	return fromAuthScheme("Bearer")
+}
+
+// FromDPoPAuthHeader 从 Authorization: DPoP <token> 读取 token
+func FromDPoPAuthHeader() TokenExtractor {
+	return fromAuthScheme("DPoP")
+}
+
+func fromAuthScheme(want string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		h := r.Header.Get("Authorization")
+		scheme, token, ok := strings.Cut(h, " ")
+		if !ok || !strings.EqualFold(scheme, want) {
+			return "", false
+		}
+		token = strings.TrimSpace(token)
+		return token, token != ""
+	}
+}
+
+// FromQuery 从 URL 查询参数读取 token（会出现在访问日志中，谨慎使用）
+func FromQuery(param string) TokenExtractor {
+	return func(r *http.Request) (string, bool) {
+		token := r.URL.Query().Get(param)
+		return token, token != ""
+	}
+}
+
+// MiddlewareConfig 认证中间件配置
+type MiddlewareConfig struct {
+	// Extractors 按顺序尝试提取 token，为空时默认 Cookie -> Authorization 头
+	Extractors []TokenExtractor
+	// OnError 认证失败时的响应，为空时返回 401 JSON
+	OnError func(w http.ResponseWriter, r *http.Request, err error)
+	// Renewal 滑动续签策略，为 nil 时不续签
+	Renewal *RenewalPolicy
+	// DPoP 校验绑定了 cnf.jkt 的 token；为 nil 时这类 token 一律被拒绝
+	DPoP *DPoPVerifier
+}
+
+type contextKey int
+
+const (
+	claimsContextKey contextKey = iota
+	tokenContextKey
+)
+
+// ContextWithClaims 把 claims 写入 context
+func ContextWithClaims(ctx context.Context, claims jwt.MapClaims) context.Context {
+	return context.WithValue(ctx, claimsContextKey, claims)
+}
+
+// ClaimsFromContext 读取中间件写入的 claims
+func ClaimsFromContext(ctx context.Context) (jwt.MapClaims, bool) {
+	claims, ok := ctx.Value(claimsContextKey).(jwt.MapClaims)
+	return claims, ok
+}
+
+// ClaimKey 读取中间件写入 context 的 claim（例如 "sub"），可直接作为 limiterUtil.KeyFunc 按用户限流
+func ClaimKey(claim string) func(r *http.Request) (string, bool) {
+	return func(r *http.Request) (string, bool) {
+		claims, ok := ClaimsFromContext(r.Context())
+		if !ok {
+			return "", false
+		}
+		v, ok := claims[claim]
+		if !ok || v == nil {
+			return "", false
+		}
+		s := fmt.Sprint(v)
+		return s, s != ""
+	}
+}
+
+// TokenFromContext 读取中间件解析过的原始 token
+func TokenFromContext(ctx context.Context) (string, bool) {
+	token, ok := ctx.Value(tokenContextKey).(string)
+	return token, ok
+}
+
+// ExtractToken 按顺序尝试各个提取器
+func ExtractToken(r *http.Request, extractors ...TokenExtractor) (string, bool) {
+	for _, ex := range extractors {
+		if token, ok := ex(r); ok {
+			return token, true
+		}
+	}
+	return "", false
+}
+
+// Middleware 标准 net/http 认证中间件：提取并校验 token，把 claims 写入请求 context
+func (m *JWTManager) Middleware(cfg MiddlewareConfig) func(http.Handler) http.Handler {
+	extractors := cfg.Extractors
+	if len(extractors) == 0 {
+		extractors = []TokenExtractor{FromCookie(m.TokenCookieName()), FromAuthHeader()}
+		if cfg.DPoP != nil {
+			extractors = append(extractors, FromDPoPAuthHeader())
+		}
+	}
+	onError := cfg.OnError
+	if onError == nil {
+		onError = defaultAuthError
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tokenStr, ok := ExtractToken(r, extractors...)
+			if !ok {
+				onError(w, r, ErrNoToken)
+				return
+			}
+			claims, err := m.ParseToken(tokenStr)
+			if err != nil {
+				onError(w, r, err)
+				return
+			}
+			if err := checkDPoP(cfg.DPoP, r, tokenStr, claims); err != nil {
+				onError(w, r, err)
+				return
+			}
+			tokenStr, claims = m.maybeRenew(w, r, tokenStr, claims, cfg.Renewal)
+
+			ctx := ContextWithClaims(r.Context(), claims)
+			ctx = context.WithValue(ctx, tokenContextKey, tokenStr)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
+// defaultAuthError 返回 401，并按 RFC 6750 / RFC 9449 写入 WWW-Authenticate
+func defaultAuthError(w http.ResponseWriter, r *http.Request, err error) {
+	code := AuthErrorCode(err)
+	desc := err.Error()
+	var te *TokenError
+	if errors.As(err, &te) {
+		desc = te.Kind.Error()
+	}
+	if errors.Is(err, ErrRevocationUnavailable) {
+		// 撤销列表故障时无法判断 token 是否有效，返回 503 而不是要求客户端重新认证
+		writeJSONError(w, http.StatusServiceUnavailable, code, desc)
+		return
+	}
+	w.Header().Set("WWW-Authenticate", authChallenge(challengeScheme(r, err), code, desc))
+	if code == "" {
+		code = "unauthorized"
+	}
+	writeJSONError(w, http.StatusUnauthorized, code, err.Error())
+}
+
+// challengeScheme DPoP 请求或 DPoP 相关错误使用 DPoP 质询，其余为 Bearer
+func challengeScheme(r *http.Request, err error) string {
+	if _, ok := FromDPoPAuthHeader()(r); ok {
+		return "DPoP"
+	}
+	for _, target := range []error{ErrDPoPProofMissing, ErrInvalidDPoPProof, ErrDPoPKeyMismatch, ErrDPoPRequired, ErrDPoPSchemeRequired} {
+		if errors.Is(err, target) {
+			return "DPoP"
+		}
+	}
+	return "Bearer"
+}
+
+// writeJSONError 输出 {"error": code, "error_description": desc}
+func writeJSONError(w http.ResponseWriter, status int, code, desc string) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(map[string]string{
+		"error":             code,
+		"error_description": desc,
+	})
+}
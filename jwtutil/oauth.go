@@ -0,0 +1,336 @@
+package jwtutil
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var ErrInvalidClient = errors.New("invalid client")
+
+// ClientAuthenticator 校验 OAuth2 客户端凭据
+type ClientAuthenticator interface {
+	AuthenticateClient(clientID, clientSecret string) error
+}
+
+// StaticClients 固定的 client_id -> client_secret 表
+type StaticClients map[string]string
+
+func (s StaticClients) AuthenticateClient(clientID, clientSecret string) error {
+	secret, ok := s[clientID]
+	if !ok || subtle.ConstantTimeCompare([]byte(secret), []byte(clientSecret)) != 1 {
+		return ErrInvalidClient
+	}
+	return nil
+}
+
+// clientCredentials 读取 client_secret_basic 或 client_secret_post 凭据
+func clientCredentials(r *http.Request) (string, string, bool) {
+	if id, secret, ok := r.BasicAuth(); ok {
+		// RFC 6749 2.3.1：Basic 中的凭据经过 form 编码
+		id, err1 := url.QueryUnescape(id)
+		secret, err2 := url.QueryUnescape(secret)
+		return id, secret, err1 == nil && err2 == nil
+	}
+	id, secret := r.PostFormValue("client_id"), r.PostFormValue("client_secret")
+	return id, secret, id != "" && secret != ""
+}
+
+// authenticateClient 认证失败时写出 401 并返回 false
+func authenticateClient(w http.ResponseWriter, r *http.Request, clients ClientAuthenticator) (string, bool) {
+	if r.Method != http.MethodPost {
+		w.Header().Set("Allow", http.MethodPost)
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return "", false
+	}
+	id, secret, ok := clientCredentials(r)
+	if !ok || clients.AuthenticateClient(id, secret) != nil {
+		w.Header().Set("WWW-Authenticate", `Basic realm="oauth"`)
+		writeJSONError(w, http.StatusUnauthorized, "invalid_client", "client authentication failed")
+		return "", false
+	}
+	return id, true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(v)
+}
+
+// token_type_hint 取值（RFC 7009 2.1）
+const (
+	accessTokenHint  = "access_token"
+	refreshTokenHint = "refresh_token"
+)
+
+// oauthTokens 内省与撤销端点可识别的 token：access token，以及 pair 不为 nil 时的 refresh token
+type oauthTokens struct {
+	access *JWTManager
+	pair   *PairManager
+}
+
+// parse 按 token_type_hint 决定先尝试哪种 token，提示不符时继续尝试另一种，返回 claims 与 token 类型
+func (o oauthTokens) parse(token, hint string) (jwt.MapClaims, string, error) {
+	kinds := []string{accessTokenHint, refreshTokenHint}
+	if hint == refreshTokenHint {
+		kinds = []string{refreshTokenHint, accessTokenHint}
+	}
+	var firstErr error
+	for _, kind := range kinds {
+		claims, err := o.parseAs(token, kind)
+		if err == nil {
+			return claims, kind, nil
+		}
+		if firstErr == nil || errors.Is(err, ErrRevocationUnavailable) {
+			firstErr = err
+		}
+	}
+	return nil, "", firstErr
+}
+
+func (o oauthTokens) parseAs(token, kind string) (jwt.MapClaims, error) {
+	if kind == accessTokenHint {
+		return o.access.ParseToken(token)
+	}
+	if o.pair == nil {
+		return nil, ErrNotRefreshToken
+	}
+	claims, err := o.pair.Refresh.parseClaims(token)
+	if err != nil {
+		return nil, err
+	}
+	if family, jti := refreshIDs(claims); family == "" || jti == "" {
+		return nil, ErrNotRefreshToken
+	}
+	return claims, nil
+}
+
+// unsupportedRefresh 没有 PairManager 时无法撤销 refresh token 所在的家族
+func (o oauthTokens) unsupportedRefresh(token, hint string) bool {
+	if o.pair != nil {
+		return false
+	}
+	if hint == refreshTokenHint {
+		return true
+	}
+	claims, err := o.access.parseClaims(token)
+	return err == nil && claims["typ"] == refreshTokenType
+}
+
+// IntrospectionHandler RFC 7662 token 内省端点，只识别 access token
+// 有效 token 返回 {"active": true, ...claims}，其余情况一律返回 {"active": false}
+func IntrospectionHandler(m *JWTManager, clients ClientAuthenticator) http.Handler {
+	return oauthTokens{access: m}.introspectionHandler(clients)
+}
+
+// IntrospectionHandler 同时识别 access token 与 refresh token 的内省端点
+// refresh token 已使用或所在家族已撤销时返回 {"active": false}
+func (p *PairManager) IntrospectionHandler(clients ClientAuthenticator) http.Handler {
+	return oauthTokens{access: p.Access, pair: p}.introspectionHandler(clients)
+}
+
+func (o oauthTokens) introspectionHandler(clients ClientAuthenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, ok := authenticateClient(w, r, clients); !ok {
+			return
+		}
+		token := r.PostFormValue("token")
+		if token == "" {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "missing token")
+			return
+		}
+		claims, kind, err := o.parse(token, r.PostFormValue("token_type_hint"))
+		if err == nil && kind == refreshTokenHint {
+			err = o.pair.checkRefresh(claims)
+		}
+		if errors.Is(err, ErrRevocationUnavailable) {
+			writeJSONError(w, http.StatusServiceUnavailable, "temporarily_unavailable", err.Error())
+			return
+		}
+		if err != nil {
+			writeJSON(w, map[string]interface{}{"active": false})
+			return
+		}
+		resp := copyClaims(claims, nil)
+		resp["active"] = true
+		if _, ok := resp["token_type"]; !ok && kind == accessTokenHint {
+			resp["token_type"] = "Bearer"
+		}
+		writeJSON(w, resp)
+	})
+}
+
+// RevocationHandler RFC 7009 token 撤销端点，只识别 access token，需要 JWTManager 配置了撤销列表
+// 按规范，无效或已撤销的 token 也返回 200；token 带有 client_id 时只允许该客户端撤销，
+// 其它客户端按 RFC 7009 2.1 拒绝并返回 unauthorized_client；refresh token 返回 unsupported_token_type
+func RevocationHandler(m *JWTManager, clients ClientAuthenticator) http.Handler {
+	return oauthTokens{access: m}.revocationHandler(clients)
+}
+
+// RevocationHandler 同时识别 access token 与 refresh token 的撤销端点
+// 撤销 refresh token 时通过 RefreshStore 撤销它所在的整个家族
+func (p *PairManager) RevocationHandler(clients ClientAuthenticator) http.Handler {
+	return oauthTokens{access: p.Access, pair: p}.revocationHandler(clients)
+}
+
+func (o oauthTokens) revocationHandler(clients ClientAuthenticator) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		clientID, ok := authenticateClient(w, r, clients)
+		if !ok {
+			return
+		}
+		token := r.PostFormValue("token")
+		if token == "" {
+			writeJSONError(w, http.StatusBadRequest, "invalid_request", "missing token")
+			return
+		}
+		hint := r.PostFormValue("token_type_hint")
+		if claims, kind, err := o.parse(token, hint); err == nil {
+			if owner, _ := claims["client_id"].(string); owner != "" && owner != clientID {
+				writeJSONError(w, http.StatusBadRequest, "unauthorized_client", "token was issued to another client")
+				return
+			}
+			if kind == refreshTokenHint {
+				family, _ := refreshIDs(claims)
+				err = o.pair.Store.RevokeFamily(family)
+			} else {
+				err = o.access.RevokeToken(token)
+			}
+			if err != nil {
+				writeJSONError(w, http.StatusServiceUnavailable, "temporarily_unavailable", err.Error())
+				return
+			}
+		} else if errors.Is(err, ErrRevocationUnavailable) {
+			writeJSONError(w, http.StatusServiceUnavailable, "temporarily_unavailable", err.Error())
+			return
+		} else if o.unsupportedRefresh(token, hint) {
+			writeJSONError(w, http.StatusBadRequest, "unsupported_token_type", "refresh token revocation is not supported")
+			return
+		}
+		w.Header().Set("Cache-Control", "no-store")
+		w.WriteHeader(http.StatusOK)
+	})
+}
+
+// ---------------------------
+// 内省客户端
+// ---------------------------
+
+// IntrospectionResult 内省结果
+type IntrospectionResult struct {
+	Active bool
+	Claims jwt.MapClaims // 内省端点返回的全部字段
+}
+
+type introspectionEntry struct {
+	result   *IntrospectionResult
+	expireAt time.Time
+}
+
+// IntrospectionClient 调用远端内省端点并缓存结果
+// 有效 token 的缓存时间不超过 CacheTTL 与 token 的 exp；无效结果缓存 CacheTTL
+type IntrospectionClient struct {
+	URL          string
+	ClientID     string
+	ClientSecret string
+	Client       *http.Client
+	CacheTTL     time.Duration // 0 表示不缓存
+
+	mu    sync.Mutex
+	cache map[string]introspectionEntry
+}
+
+func NewIntrospectionClient(endpoint, clientID, clientSecret string) *IntrospectionClient {
+	return &IntrospectionClient{
+		URL:          endpoint,
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+		Client:       &http.Client{Timeout: 10 * time.Second},
+		CacheTTL:     time.Minute,
+		cache:        make(map[string]introspectionEntry),
+	}
+}
+
+func cacheKey(token string) string {
+	h := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(h[:])
+}
+
+// Introspect 查询 token 状态，优先使用缓存
+func (c *IntrospectionClient) Introspect(ctx context.Context, token string) (*IntrospectionResult, error) {
+	key := cacheKey(token)
+	now := time.Now()
+	c.mu.Lock()
+	if e, ok := c.cache[key]; ok && now.Before(e.expireAt) {
+		c.mu.Unlock()
+		return e.result, nil
+	}
+	c.mu.Unlock()
+
+	result, err := c.fetch(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	if c.CacheTTL > 0 {
+		expireAt := now.Add(c.CacheTTL)
+		if exp, err := result.Claims.GetExpirationTime(); err == nil && exp != nil && exp.Before(expireAt) {
+			expireAt = exp.Time
+		}
+		c.mu.Lock()
+		c.purgeLocked(now)
+		c.cache[key] = introspectionEntry{result: result, expireAt: expireAt}
+		c.mu.Unlock()
+	}
+	return result, nil
+}
+
+func (c *IntrospectionClient) fetch(ctx context.Context, token string) (*IntrospectionResult, error) {
+	form := url.Values{"token": {token}, "token_type_hint": {"access_token"}}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.URL, strings.NewReader(form.Encode()))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	req.Header.Set("Accept", "application/json")
+	req.SetBasicAuth(url.QueryEscape(c.ClientID), url.QueryEscape(c.ClientSecret))
+
+	resp, err := c.Client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("introspection failed: %s", resp.Status)
+	}
+
+	var claims jwt.MapClaims
+	if err := json.NewDecoder(resp.Body).Decode(&claims); err != nil {
+		return nil, err
+	}
+	active, _ := claims["active"].(bool)
+	return &IntrospectionResult{Active: active, Claims: claims}, nil
+}
+
+// purgeLocked 缓存较大时清理过期项
+func (c *IntrospectionClient) purgeLocked(now time.Time) {
+	if len(c.cache) < 1024 {
+		return
+	}
+	for k, e := range c.cache {
+		if !now.Before(e.expireAt) {
+			delete(c.cache, k)
+		}
+	}
+}
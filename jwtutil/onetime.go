@@ -0,0 +1,128 @@
+package jwtutil
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"errors"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PurposeClaim 一次性 token 的用途 claim
+const PurposeClaim = "purpose"
+
+const bindingClaim = "bnd"
+
+var (
+	ErrWrongPurpose     = errors.New("token purpose mismatch")
+	ErrTokenAlreadyUsed = errors.New("token already used")
+	ErrBindingMismatch  = errors.New("token binding mismatch")
+	ErrNotOneTimeToken  = errors.New("not a one-time token")
+)
+
+// OneTimeStore 记录已使用的一次性 token
+type OneTimeStore interface {
+	// Consume 原子地把 jti 标记为已使用，已使用过时返回 ErrTokenAlreadyUsed
+	// expireAt 之后记录可被清理（token 本身已过期）
+	Consume(jti string, expireAt time.Time) error
+}
+
+// OneTimeTokens 邮箱验证、密码重置、魔法链接等单用途一次性 token
+// token 带有 purpose claim，同一管理器的 ParseToken 与中间件会拒绝它，不会被当作会话 token
+type OneTimeTokens struct {
+	Manager *JWTManager
+	Store   OneTimeStore
+}
+
+func NewOneTimeTokens(m *JWTManager, store OneTimeStore) *OneTimeTokens {
+	return &OneTimeTokens{Manager: m, Store: store}
+}
+
+// bindingDigest 以 jti 作盐计算绑定值摘要，token 中不会出现原始绑定值
+func bindingDigest(jti string, binding []byte) string {
+	h := sha256.New()
+	h.Write([]byte(jti))
+	h.Write(binding)
+	return base64.RawURLEncoding.EncodeToString(h.Sum(nil))
+}
+
+// Issue 签发一次性 token
+// binding 可选，通常传用户当前的密码哈希：密码修改后 token 自动失效
+func (o *OneTimeTokens) Issue(purpose, subject string, ttl time.Duration, binding []byte, extra jwt.MapClaims) (string, error) {
+	if purpose == "" {
+		return "", errors.New("purpose is empty")
+	}
+	claims := copyClaims(extra, nil)
+	claims[PurposeClaim] = purpose
+	claims["sub"] = subject
+	o.Manager.fillRegisteredClaims(claims)
+	claims["exp"] = o.Manager.now().Add(ttl).Unix()
+	if binding != nil {
+		claims[bindingClaim] = bindingDigest(claims["jti"].(string), binding)
+	}
+	return o.Manager.sign(claims)
+}
+
+// Consume 校验用途与绑定后原子地消费 token，第二次调用返回 ErrTokenAlreadyUsed
+// 签发时传了 binding 的 token，消费时必须传入相同的值
+func (o *OneTimeTokens) Consume(tokenStr, purpose string, binding []byte) (jwt.MapClaims, error) {
+	claims, err := o.Manager.parseClaims(tokenStr)
+	if err != nil {
+		return nil, err
+	}
+	if claims[PurposeClaim] != purpose {
+		return nil, ErrWrongPurpose
+	}
+	jti, _ := claims["jti"].(string)
+	exp, _ := claims.GetExpirationTime()
+	if jti == "" || exp == nil {
+		return nil, ErrNotOneTimeToken
+	}
+	if want, ok := claims[bindingClaim].(string); ok {
+		got := bindingDigest(jti, binding)
+		if subtle.ConstantTimeCompare([]byte(want), []byte(got)) != 1 {
+			return nil, ErrBindingMismatch
+		}
+	}
+	if err := o.Store.Consume(jti, exp.Time); err != nil {
+		return nil, err
+	}
+	return claims, nil
+}
+
+// ---------------------------
+// 内存实现
+// ---------------------------
+
+// MemoryOneTimeStore 基于内存的 OneTimeStore，适合单实例部署
+type MemoryOneTimeStore struct {
+	mu        sync.Mutex
+	used      map[string]time.Time
+	lastPurge time.Time
+}
+
+func NewMemoryOneTimeStore() *MemoryOneTimeStore {
+	return &MemoryOneTimeStore{used: make(map[string]time.Time)}
+}
+
+func (s *MemoryOneTimeStore) Consume(jti string, expireAt time.Time) error {
+	now := time.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now.Sub(s.lastPurge) >= time.Minute {
+		s.lastPurge = now
+		for k, exp := range s.used {
+			if now.After(exp) {
+				delete(s.used, k)
+			}
+		}
+	}
+	if _, ok := s.used[jti]; ok {
+		return ErrTokenAlreadyUsed
+	}
+	s.used[jti] = expireAt
+	return nil
+}
@@ -0,0 +1,304 @@
+package jwtutil
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+var (
+	ErrNotRefreshToken    = errors.New("not a refresh token")
+	ErrRefreshTokenReused = errors.New("refresh token reused, token family revoked")
+	ErrTokenFamilyRevoked = errors.New("token family revoked")
+	ErrUnknownRefresh     = errors.New("unknown refresh token")
+)
+
+const refreshTokenType = "refresh"
+
+// pair 内部使用的 claim，刷新时不会复制到新 token
+var pairReservedClaims = []string{"exp", "iat", "nbf", "jti", "iss", "aud", "typ", "fam"}
+
+// TokenPair 一次签发的 access/refresh token 对
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+}
+
+// RefreshStore 记录 refresh token 的使用情况，用于轮换与重放检测
+type RefreshStore interface {
+	// Save 记录新签发的 refresh token
+	Save(family, jti string, expireAt time.Time) error
+	// Use 原子地把 jti 标记为已使用
+	// 已使用过时撤销整个家族并返回 ErrRefreshTokenReused
+	Use(family, jti string) error
+	// RevokeFamily 撤销整个家族
+	RevokeFamily(family string) error
+}
+
+// refreshChecker 可选接口：查询 refresh token 是否仍可使用（未使用且家族未撤销），供内省端点使用
+// 未实现时内省端点只校验签名与有效期
+type refreshChecker interface {
+	Check(family, jti string) error
+}
+
+// PairManager 同时签发短期 access token 与长期 refresh token
+// refresh token 带有 typ=refresh，Access.ParseToken 与中间件会拒绝它；Access 与 Refresh 仍建议使用不同的密钥
+type PairManager struct {
+	Access  *JWTManager
+	Refresh *JWTManager
+	Store   RefreshStore
+}
+
+func NewPairManager(access, refresh *JWTManager, store RefreshStore) *PairManager {
+	return &PairManager{
+		Access:  access,
+		Refresh: refresh,
+		Store:   store,
+	}
+}
+
+func copyClaims(claims jwt.MapClaims, skip []string) jwt.MapClaims {
+	out := make(jwt.MapClaims, len(claims))
+	for k, v := range claims {
+		out[k] = v
+	}
+	for _, k := range skip {
+		delete(out, k)
+	}
+	return out
+}
+
+// IssuePair 签发新的 token 对，开启一个新的 refresh 家族
+func (p *PairManager) IssuePair(claims jwt.MapClaims) (*TokenPair, error) {
+	return p.issue(claims, newTokenID())
+}
+
+func (p *PairManager) issue(claims jwt.MapClaims, family string) (*TokenPair, error) {
+	access, err := p.Access.GenerateToken(copyClaims(claims, pairReservedClaims))
+	if err != nil {
+		return nil, err
+	}
+
+	jti := newTokenID()
+	rc := copyClaims(claims, pairReservedClaims)
+	rc["typ"] = refreshTokenType
+	rc["fam"] = family
+	rc["jti"] = jti
+	refresh, err := p.Refresh.GenerateToken(rc)
+	if err != nil {
+		return nil, err
+	}
+	if err := p.Store.Save(family, jti, p.Refresh.now().Add(p.Refresh.TokenDuration)); err != nil {
+		return nil, err
+	}
+
+	return &TokenPair{
+		AccessToken:  access,
+		RefreshToken: refresh,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(p.Access.TokenDuration.Seconds()),
+	}, nil
+}
+
+// RefreshPair 用 refresh token 换取新的 token 对，旧 refresh token 随即失效
+// 已使用过的 refresh token 再次出现时，整个家族都会被撤销
+func (p *PairManager) RefreshPair(refreshToken string) (*TokenPair, error) {
+	claims, err := p.Refresh.parseClaims(refreshToken)
+	if err != nil {
+		return nil, err
+	}
+	family, jti := refreshIDs(claims)
+	if family == "" || jti == "" {
+		return nil, ErrNotRefreshToken
+	}
+	if err := p.Store.Use(family, jti); err != nil {
+		return nil, err
+	}
+	return p.issue(claims, family)
+}
+
+// refreshIDs 返回 refresh token 的家族与 jti，不是 refresh token 时返回空串
+func refreshIDs(claims jwt.MapClaims) (family, jti string) {
+	if claims["typ"] != refreshTokenType {
+		return "", ""
+	}
+	family, _ = claims["fam"].(string)
+	jti, _ = claims["jti"].(string)
+	return family, jti
+}
+
+// checkRefresh 存储支持时确认 refresh token 仍可使用
+func (p *PairManager) checkRefresh(claims jwt.MapClaims) error {
+	c, ok := p.Store.(refreshChecker)
+	if !ok {
+		return nil
+	}
+	family, jti := refreshIDs(claims)
+	return c.Check(family, jti)
+}
+
+// Revoke 撤销 refresh token 所在的整个家族（例如登出）
+func (p *PairManager) Revoke(refreshToken string) error {
+	claims, err := p.Refresh.parseClaims(refreshToken)
+	if err != nil {
+		return err
+	}
+	family, _ := refreshIDs(claims)
+	if family == "" {
+		return ErrNotRefreshToken
+	}
+	return p.Store.RevokeFamily(family)
+}
+
+// RefreshHandler 刷新端点：从表单 refresh_token 或 Refresh 的 Cookie 读取 refresh token
+// 成功时返回 JSON 格式的 TokenPair；若 token 来自 Cookie，同时写回新的 Cookie
+func (p *PairManager) RefreshHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			w.Header().Set("Allow", http.MethodPost)
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		fromCookie := false
+		token := r.PostFormValue("refresh_token")
+		if token == "" {
+			c, err := p.Refresh.ReadTokenFromCookie(r)
+			if err != nil {
+				writeJSONError(w, http.StatusBadRequest, "invalid_request", "missing refresh token")
+				return
+			}
+			token, fromCookie = c, true
+		}
+
+		pair, err := p.RefreshPair(token)
+		if err != nil {
+			writeJSONError(w, http.StatusUnauthorized, "invalid_grant", err.Error())
+			return
+		}
+		if fromCookie {
+			p.Access.SetTokenCookie(w, pair.AccessToken)
+			p.Refresh.SetTokenCookie(w, pair.RefreshToken)
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		json.NewEncoder(w).Encode(pair)
+	})
+}
+
+// ---------------------------
+// 内存实现
+// ---------------------------
+
+type refreshRecord struct {
+	family   string
+	used     bool
+	expireAt time.Time
+}
+
+// MemoryRefreshStore 基于内存的 RefreshStore，适合单实例部署
+type MemoryRefreshStore struct {
+	mu        sync.Mutex
+	tokens    map[string]*refreshRecord // jti -> 记录
+	revoked   map[string]time.Time      // 已撤销家族 -> 过期清理时间
+	lastPurge time.Time
+}
+
+func NewMemoryRefreshStore() *MemoryRefreshStore {
+	return &MemoryRefreshStore{
+		tokens:  make(map[string]*refreshRecord),
+		revoked: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryRefreshStore) Save(family, jti string, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.purgeLocked(time.Now())
+	if _, ok := s.revoked[family]; ok {
+		return ErrTokenFamilyRevoked
+	}
+	s.tokens[jti] = &refreshRecord{family: family, expireAt: expireAt}
+	return nil
+}
+
+func (s *MemoryRefreshStore) Use(family, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revoked[family]; ok {
+		return ErrTokenFamilyRevoked
+	}
+	rec, ok := s.tokens[jti]
+	if !ok || rec.family != family {
+		return ErrUnknownRefresh
+	}
+	if rec.used {
+		s.revokeFamilyLocked(family)
+		return ErrRefreshTokenReused
+	}
+	rec.used = true
+	return nil
+}
+
+// Check 确认 refresh token 未使用且家族未撤销，不改变状态
+func (s *MemoryRefreshStore) Check(family, jti string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, ok := s.revoked[family]; ok {
+		return ErrTokenFamilyRevoked
+	}
+	rec, ok := s.tokens[jti]
+	if !ok || rec.family != family {
+		return ErrUnknownRefresh
+	}
+	if rec.used {
+		return ErrRefreshTokenReused
+	}
+	return nil
+}
+
+func (s *MemoryRefreshStore) RevokeFamily(family string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.revokeFamilyLocked(family)
+	return nil
+}
+
+// revokeFamilyLocked 假设已经持有 mu
+func (s *MemoryRefreshStore) revokeFamilyLocked(family string) {
+	var latest time.Time
+	for jti, rec := range s.tokens {
+		if rec.family == family {
+			if rec.expireAt.After(latest) {
+				latest = rec.expireAt
+			}
+			delete(s.tokens, jti)
+		}
+	}
+	s.revoked[family] = latest
+}
+
+// purgeLocked 清理过期记录，最多每分钟一次
+func (s *MemoryRefreshStore) purgeLocked(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Minute {
+		return
+	}
+	s.lastPurge = now
+	for jti, rec := range s.tokens {
+		if now.After(rec.expireAt) {
+			delete(s.tokens, jti)
+		}
+	}
+	for family, until := range s.revoked {
+		if now.After(until) {
+			delete(s.revoked, family)
+		}
+	}
+}
@@ -0,0 +1,318 @@
+package jwtutil
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"golang.org/x/crypto/blake2b"
+	"golang.org/x/crypto/chacha20"
+)
+
+// TokenManager JWTManager 与 PasetoManager 的公共接口，更换构造函数即可切换 token 格式
+type TokenManager interface {
+	GenerateToken(claims jwt.MapClaims) (string, error)
+	ParseToken(tokenStr string) (jwt.MapClaims, error)
+	SetTokenCookie(w http.ResponseWriter, token string)
+	ReadTokenFromCookie(r *http.Request) (string, error)
+}
+
+var (
+	_ TokenManager = (*JWTManager)(nil)
+	_ TokenManager = (*PasetoManager)(nil)
+)
+
+const (
+	pasetoLocalHeader  = "v4.local."
+	pasetoPublicHeader = "v4.public."
+)
+
+// PasetoManager PASETO v4 token 管理器
+// v4.local 使用 XChaCha20 + BLAKE2b 对称加密，v4.public 使用 Ed25519 签名
+// 版本与算法由 token 头部固定，不存在 JWT 的算法混淆问题
+type PasetoManager struct {
+	TokenDuration time.Duration
+	CookieName    string
+	Leeway        time.Duration // 校验 exp/nbf/iat 时容忍的时钟偏差
+
+	header    string
+	localKey  []byte
+	signKey   ed25519.PrivateKey
+	verifyKey ed25519.PublicKey
+
+	cookiePolicy
+}
+
+// NewPasetoLocalManager 创建 v4.local 管理器，key 为 32 字节对称密钥
+func NewPasetoLocalManager(key []byte, duration time.Duration, cookieName string) (*PasetoManager, error) {
+	if len(key) != 32 {
+		return nil, errors.New("paseto: v4.local requires a 32-byte key")
+	}
+	return &PasetoManager{
+		TokenDuration: duration,
+		CookieName:    cookieName,
+		header:        pasetoLocalHeader,
+		localKey:      append([]byte(nil), key...),
+	}, nil
+}
+
+// NewPasetoPublicManager 创建 v4.public 管理器，既可签发也可校验
+func NewPasetoPublicManager(privateKey ed25519.PrivateKey, duration time.Duration, cookieName string) (*PasetoManager, error) {
+	if len(privateKey) != ed25519.PrivateKeySize {
+		return nil, errors.New("paseto: invalid Ed25519 private key")
+	}
+	return &PasetoManager{
+		TokenDuration: duration,
+		CookieName:    cookieName,
+		header:        pasetoPublicHeader,
+		signKey:       privateKey,
+		verifyKey:     privateKey.Public().(ed25519.PublicKey),
+	}, nil
+}
+
+// NewPasetoVerifier 创建只校验的 v4.public 管理器
+func NewPasetoVerifier(publicKey ed25519.PublicKey, cookieName string) (*PasetoManager, error) {
+	if len(publicKey) != ed25519.PublicKeySize {
+		return nil, errors.New("paseto: invalid Ed25519 public key")
+	}
+	return &PasetoManager{
+		CookieName: cookieName,
+		header:     pasetoPublicHeader,
+		verifyKey:  publicKey,
+	}, nil
+}
+
+// GenerateToken 生成 PASETO，exp/iat/nbf 按规范写为 RFC 3339 字符串，缺失时补上 jti
+func (m *PasetoManager) GenerateToken(claims jwt.MapClaims) (string, error) {
+	if m.header == pasetoPublicHeader && m.signKey == nil {
+		return "", ErrNoSigningKey
+	}
+	now := time.Now().UTC()
+	claims["exp"] = now.Add(m.TokenDuration).Format(time.RFC3339)
+	for name, v := range map[string]interface{}{
+		"iat": now.Format(time.RFC3339),
+		"nbf": now.Format(time.RFC3339),
+		"jti": newTokenID(),
+	} {
+		if _, ok := claims[name]; !ok {
+			claims[name] = v
+		}
+	}
+	payload, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	if m.header == pasetoLocalHeader {
+		return pasetoEncrypt(m.localKey, payload)
+	}
+	return pasetoSign(m.signKey, payload), nil
+}
+
+// ParseToken 解析 PASETO 并校验 exp/nbf/iat
+func (m *PasetoManager) ParseToken(tokenStr string) (jwt.MapClaims, error) {
+	var payload []byte
+	var err error
+	if m.header == pasetoLocalHeader {
+		payload, err = pasetoDecrypt(m.localKey, tokenStr)
+	} else {
+		payload, err = pasetoVerify(m.verifyKey, tokenStr)
+	}
+	if err != nil {
+		return nil, asTokenError(err)
+	}
+
+	var claims jwt.MapClaims
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return nil, &TokenError{Kind: ErrTokenMalformed, Err: err}
+	}
+	if err := m.validateTimes(claims); err != nil {
+		return nil, asTokenError(err)
+	}
+	return claims, nil
+}
+
+func pasetoTime(claims jwt.MapClaims, name string) (time.Time, bool, error) {
+	v, ok := claims[name]
+	if !ok {
+		return time.Time{}, false, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, false, fmt.Errorf("%w: %s is not a string", ErrInvalidClaims, name)
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, false, fmt.Errorf("%w: %w", ErrInvalidClaims, err)
+	}
+	return t, true, nil
+}
+
+func (m *PasetoManager) validateTimes(claims jwt.MapClaims) error {
+	now := time.Now()
+	exp, ok, err := pasetoTime(claims, "exp")
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrMissingClaim
+	}
+	if !now.Before(exp.Add(m.Leeway)) {
+		return ErrTokenExpired
+	}
+	if nbf, ok, err := pasetoTime(claims, "nbf"); err != nil {
+		return err
+	} else if ok && now.Before(nbf.Add(-m.Leeway)) {
+		return ErrTokenNotValidYet
+	}
+	if iat, ok, err := pasetoTime(claims, "iat"); err != nil {
+		return err
+	} else if ok && now.Before(iat.Add(-m.Leeway)) {
+		return ErrTokenUsedBeforeIssued
+	}
+	return nil
+}
+
+// TokenCookieName 返回带前缀的实际 Cookie 名
+func (m *PasetoManager) TokenCookieName() string {
+	return m.CookieOptions().Prefix + m.CookieName
+}
+
+// 写 HttpOnly Cookie，属性由 SetCookieOptions 配置
+func (m *PasetoManager) SetTokenCookie(w http.ResponseWriter, token string) {
+	http.SetCookie(w, m.buildCookie(m.CookieName, token, int(m.TokenDuration.Seconds())))
+}
+
+// 从请求读取 Cookie 中的 PASETO
+func (m *PasetoManager) ReadTokenFromCookie(r *http.Request) (string, error) {
+	c, err := r.Cookie(m.TokenCookieName())
+	if err != nil {
+		return "", err
+	}
+	return c.Value, nil
+}
+
+// ClearTokenCookie 删除 token Cookie（登出）
+func (m *PasetoManager) ClearTokenCookie(w http.ResponseWriter) {
+	http.SetCookie(w, m.buildCookie(m.CookieName, "", -1))
+}
+
+// ---------------------------
+// PASETO v4 协议实现（不支持 footer 与 implicit assertion）
+// ---------------------------
+
+// pae Pre-Authentication Encoding
+func pae(pieces ...[]byte) []byte {
+	out := binary.LittleEndian.AppendUint64(nil, uint64(len(pieces)))
+	for _, p := range pieces {
+		out = binary.LittleEndian.AppendUint64(out, uint64(len(p))&^(1<<63))
+		out = append(out, p...)
+	}
+	return out
+}
+
+func blake2bKeyed(size int, key []byte, parts ...[]byte) []byte {
+	h, err := blake2b.New(size, key)
+	if err != nil {
+		panic(err)
+	}
+	for _, p := range parts {
+		h.Write(p)
+	}
+	return h.Sum(nil)
+}
+
+// pasetoLocalKeys 由随机数 n 派生加密密钥、XChaCha20 nonce 与认证密钥
+func pasetoLocalKeys(key, n []byte) (ek, n2, ak []byte) {
+	tmp := blake2bKeyed(56, key, []byte("paseto-encryption-key"), n)
+	ak = blake2bKeyed(32, key, []byte("paseto-auth-key-for-aead"), n)
+	return tmp[:32], tmp[32:], ak
+}
+
+func pasetoEncrypt(key, message []byte) (string, error) {
+	n := make([]byte, 32)
+	if _, err := io.ReadFull(rand.Reader, n); err != nil {
+		return "", err
+	}
+	ek, n2, ak := pasetoLocalKeys(key, n)
+	c := make([]byte, len(message))
+	stream, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return "", err
+	}
+	stream.XORKeyStream(c, message)
+
+	t := blake2bKeyed(32, ak, pae([]byte(pasetoLocalHeader), n, c, nil, nil))
+	body := append(append(append([]byte{}, n...), c...), t...)
+	return pasetoLocalHeader + base64.RawURLEncoding.EncodeToString(body), nil
+}
+
+func pasetoDecrypt(key []byte, token string) ([]byte, error) {
+	body, err := pasetoBody(token, pasetoLocalHeader)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < 64 {
+		return nil, ErrTokenMalformed
+	}
+	n, c, t := body[:32], body[32:len(body)-32], body[len(body)-32:]
+
+	ek, n2, ak := pasetoLocalKeys(key, n)
+	t2 := blake2bKeyed(32, ak, pae([]byte(pasetoLocalHeader), n, c, nil, nil))
+	if subtle.ConstantTimeCompare(t, t2) != 1 {
+		return nil, ErrInvalidSignature
+	}
+	stream, err := chacha20.NewUnauthenticatedCipher(ek, n2)
+	if err != nil {
+		return nil, err
+	}
+	m := make([]byte, len(c))
+	stream.XORKeyStream(m, c)
+	return m, nil
+}
+
+func pasetoSign(key ed25519.PrivateKey, message []byte) string {
+	sig := ed25519.Sign(key, pae([]byte(pasetoPublicHeader), message, nil, nil))
+	body := append(append([]byte{}, message...), sig...)
+	return pasetoPublicHeader + base64.RawURLEncoding.EncodeToString(body)
+}
+
+func pasetoVerify(key ed25519.PublicKey, token string) ([]byte, error) {
+	body, err := pasetoBody(token, pasetoPublicHeader)
+	if err != nil {
+		return nil, err
+	}
+	if len(body) < ed25519.SignatureSize {
+		return nil, ErrTokenMalformed
+	}
+	m, sig := body[:len(body)-ed25519.SignatureSize], body[len(body)-ed25519.SignatureSize:]
+	if !ed25519.Verify(key, pae([]byte(pasetoPublicHeader), m, nil, nil), sig) {
+		return nil, ErrInvalidSignature
+	}
+	return m, nil
+}
+
+// pasetoBody 检查头部并解码主体，带 footer 的 token 会被拒绝
+func pasetoBody(token, header string) ([]byte, error) {
+	if !strings.HasPrefix(token, header) {
+		return nil, ErrTokenMalformed
+	}
+	rest := token[len(header):]
+	if strings.Contains(rest, ".") {
+		return nil, ErrTokenMalformed
+	}
+	body, err := base64.RawURLEncoding.DecodeString(rest)
+	if err != nil {
+		return nil, ErrTokenMalformed
+	}
+	return body, nil
+}
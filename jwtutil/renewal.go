@@ -0,0 +1,107 @@
+package jwtutil
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	// AuthTimeClaim 记录会话首次登录时间的 claim，续签时保持不变
+	AuthTimeClaim = "auth_time"
+	// SessionClaim 续签出的 token 记录会话标识（首个 token 的 jti），续签时保持不变
+	SessionClaim = "sid"
+)
+
+// RenewalPolicy 滑动续签策略
+// token 已用掉的生命周期比例超过 Threshold 时，中间件通过 SetTokenCookie 下发新 token；
+// 会话从 auth_time（缺失时取 iat）起最多存活 MaxAge，新 token 的 exp 不会超过该上限
+type RenewalPolicy struct {
+	Threshold float64       // 0~1，默认 0.5
+	MaxAge    time.Duration // 会话绝对最长时间，0 表示不限制
+}
+
+func claimTime(claims jwt.MapClaims, name string) (time.Time, bool) {
+	switch v := claims[name].(type) {
+	case float64:
+		return time.Unix(int64(v), 0), true
+	case int64:
+		return time.Unix(v, 0), true
+	case int:
+		return time.Unix(int64(v), 0), true
+	}
+	return time.Time{}, false
+}
+
+// sessionID 会话标识：续签出的 token 取 sid，首次签发的 token 取 jti
+func sessionID(claims jwt.MapClaims) string {
+	if sid, _ := claims[SessionClaim].(string); sid != "" {
+		return sid
+	}
+	jti, _ := claims["jti"].(string)
+	return jti
+}
+
+// renew 按策略判断是否需要续签，需要时返回新 token 及其 claims
+// 续签生成新的 jti，并通过 sid 保留会话标识，撤销与 CSRF 都按 sid 绑定会话
+func (m *JWTManager) renew(claims jwt.MapClaims, policy *RenewalPolicy) (string, jwt.MapClaims, bool, error) {
+	now := m.now()
+	iat, ok1 := claimTime(claims, "iat")
+	exp, ok2 := claimTime(claims, "exp")
+	if !ok1 || !ok2 || !exp.After(iat) {
+		return "", nil, false, nil
+	}
+	threshold := policy.Threshold
+	if threshold <= 0 || threshold > 1 {
+		threshold = 0.5
+	}
+	if float64(now.Sub(iat)) < threshold*float64(exp.Sub(iat)) {
+		return "", nil, false, nil
+	}
+
+	authTime, ok := claimTime(claims, AuthTimeClaim)
+	if !ok {
+		authTime = iat
+	}
+	newExp := now.Add(m.TokenDuration)
+	if policy.MaxAge > 0 {
+		limit := authTime.Add(policy.MaxAge)
+		if !limit.After(exp) {
+			// 已经到达上限，不再续签
+			return "", nil, false, nil
+		}
+		if newExp.After(limit) {
+			newExp = limit
+		}
+	}
+
+	next := copyClaims(claims, nil)
+	next["jti"] = newTokenID()
+	next[SessionClaim] = sessionID(claims)
+	next["iat"] = issuedAtValue(now)
+	next["nbf"] = now.Unix()
+	next["exp"] = newExp.Unix()
+	next[AuthTimeClaim] = authTime.Unix()
+	token, err := m.sign(next)
+	if err != nil {
+		return "", nil, false, err
+	}
+	return token, next, true, nil
+}
+
+// maybeRenew 只对来自 Cookie 的 token 续签，返回续签后（或原来）的 token 与 claims
+func (m *JWTManager) maybeRenew(w http.ResponseWriter, r *http.Request, tokenStr string, claims jwt.MapClaims, policy *RenewalPolicy) (string, jwt.MapClaims) {
+	if policy == nil {
+		return tokenStr, claims
+	}
+	if c, err := r.Cookie(m.TokenCookieName()); err != nil || c.Value != tokenStr {
+		return tokenStr, claims
+	}
+	token, next, ok, err := m.renew(claims, policy)
+	if err != nil || !ok {
+		return tokenStr, claims
+	}
+	m.SetTokenCookie(w, token)
+	return token, next
+}
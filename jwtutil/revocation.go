@@ -0,0 +1,244 @@
+package jwtutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"os"
+	"sync"
+	"time"
+)
+
+var ErrTokenRevoked = errors.New("token revoked")
+
+// RevocationStore 撤销列表，ParseToken 校验签名后会查询它
+type RevocationStore interface {
+	// RevokeToken 撤销单个 token，expireAt 之后记录可被清理
+	RevokeToken(jti string, expireAt time.Time) error
+	// RevokeSubject 撤销 subject 在 before 及之前签发的全部 token，expireAt 之后记录可被清理
+	RevokeSubject(subject string, before, expireAt time.Time) error
+	// IsRevoked 判断 token 是否已被撤销
+	IsRevoked(jti, subject string, issuedAt time.Time) (bool, error)
+}
+
+// ---------------------------
+// 内存实现
+// ---------------------------
+
+type subjectCutoff struct {
+	before   time.Time
+	expireAt time.Time
+}
+
+// MemoryRevocationStore 基于内存的撤销列表，后台按 TTL 清理过期记录
+type MemoryRevocationStore struct {
+	mu       sync.RWMutex
+	tokens   map[string]time.Time     // jti -> 过期时间
+	subjects map[string]subjectCutoff // subject -> 撤销截止时间
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewMemoryRevocationStore(cleanupInterval time.Duration) *MemoryRevocationStore {
+	if cleanupInterval <= 0 {
+		cleanupInterval = time.Minute
+	}
+	s := &MemoryRevocationStore{
+		tokens:   make(map[string]time.Time),
+		subjects: make(map[string]subjectCutoff),
+		stopCh:   make(chan struct{}),
+	}
+	go s.cleanupLoop(cleanupInterval)
+	return s
+}
+
+func (s *MemoryRevocationStore) RevokeToken(jti string, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.tokens[jti] = expireAt
+	return nil
+}
+
+func (s *MemoryRevocationStore) RevokeSubject(subject string, before, expireAt time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	// 只会往后推，避免较早的撤销覆盖较晚的撤销
+	if cur, ok := s.subjects[subject]; ok && cur.before.After(before) {
+		return nil
+	}
+	s.subjects[subject] = subjectCutoff{before: before, expireAt: expireAt}
+	return nil
+}
+
+func (s *MemoryRevocationStore) IsRevoked(jti, subject string, issuedAt time.Time) (bool, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if jti != "" {
+		if _, ok := s.tokens[jti]; ok {
+			return true, nil
+		}
+	}
+	if subject != "" {
+		if c, ok := s.subjects[subject]; ok && !issuedAt.After(c.before) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// 后台清理过期记录
+func (s *MemoryRevocationStore) cleanupLoop(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-s.stopCh:
+			return
+		case <-ticker.C:
+			s.purge(time.Now())
+		}
+	}
+}
+
+func (s *MemoryRevocationStore) purge(now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for jti, exp := range s.tokens {
+		if now.After(exp) {
+			delete(s.tokens, jti)
+		}
+	}
+	for sub, c := range s.subjects {
+		if now.After(c.expireAt) {
+			delete(s.subjects, sub)
+		}
+	}
+}
+
+// Stop 停止后台清理
+func (s *MemoryRevocationStore) Stop() {
+	s.stopOnce.Do(func() { close(s.stopCh) })
+}
+
+// ---------------------------
+// 文件实现
+// ---------------------------
+
+// revocationRecord 文件中的一行记录
+type revocationRecord struct {
+	JTI      string    `json:"jti,omitempty"`
+	Subject  string    `json:"sub,omitempty"`
+	Before   time.Time `json:"before,omitempty"`
+	ExpireAt time.Time `json:"exp"`
+}
+
+// FileRevocationStore 以 JSON Lines 追加写入文件的撤销列表，重启后可恢复
+// 查询走内存，打开时会丢弃已过期的记录并压缩文件
+type FileRevocationStore struct {
+	*MemoryRevocationStore
+	path string
+	mu   sync.Mutex
+	f    *os.File
+}
+
+func NewFileRevocationStore(path string, cleanupInterval time.Duration) (*FileRevocationStore, error) {
+	mem := NewMemoryRevocationStore(cleanupInterval)
+	s := &FileRevocationStore{MemoryRevocationStore: mem, path: path}
+	if err := s.load(); err != nil {
+		mem.Stop()
+		return nil, err
+	}
+	return s, nil
+}
+
+// load 读取已有记录，并把未过期的记录重写到新文件
+func (s *FileRevocationStore) load() error {
+	now := time.Now()
+	var live []revocationRecord
+
+	if f, err := os.Open(s.path); err == nil {
+		sc := bufio.NewScanner(f)
+		for sc.Scan() {
+			var rec revocationRecord
+			if err := json.Unmarshal(sc.Bytes(), &rec); err != nil {
+				// 跳过损坏的行（例如写入中途崩溃）
+				continue
+			}
+			if now.After(rec.ExpireAt) {
+				continue
+			}
+			live = append(live, rec)
+			s.apply(rec)
+		}
+		f.Close()
+		if err := sc.Err(); err != nil {
+			return err
+		}
+	} else if !os.IsNotExist(err) {
+		return err
+	}
+
+	tmp := s.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return err
+	}
+	enc := json.NewEncoder(f)
+	for _, rec := range live {
+		if err := enc.Encode(rec); err != nil {
+			f.Close()
+			return err
+		}
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp, s.path); err != nil {
+		return err
+	}
+
+	s.f, err = os.OpenFile(s.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	return err
+}
+
+func (s *FileRevocationStore) apply(rec revocationRecord) {
+	if rec.JTI != "" {
+		s.MemoryRevocationStore.RevokeToken(rec.JTI, rec.ExpireAt)
+	} else if rec.Subject != "" {
+		s.MemoryRevocationStore.RevokeSubject(rec.Subject, rec.Before, rec.ExpireAt)
+	}
+}
+
+func (s *FileRevocationStore) append(rec revocationRecord) error {
+	b, err := json.Marshal(rec)
+	if err != nil {
+		return err
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if _, err := s.f.Write(append(b, '\n')); err != nil {
+		return err
+	}
+	if err := s.f.Sync(); err != nil {
+		return err
+	}
+	s.apply(rec)
+	return nil
+}
+
+func (s *FileRevocationStore) RevokeToken(jti string, expireAt time.Time) error {
+	return s.append(revocationRecord{JTI: jti, ExpireAt: expireAt})
+}
+
+func (s *FileRevocationStore) RevokeSubject(subject string, before, expireAt time.Time) error {
+	return s.append(revocationRecord{Subject: subject, Before: before, ExpireAt: expireAt})
+}
+
+// Close 停止后台清理并关闭文件
+func (s *FileRevocationStore) Close() error {
+	s.Stop()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.f.Close()
+}
@@ -0,0 +1,69 @@
+package jwtutil
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// ClaimsValidator 自定义 claims 可实现该接口，ParseTokenAs 在解码后调用
+type ClaimsValidator interface {
+	Validate() error
+}
+
+// GenerateTokenFor 使用自定义 claims 结构体生成 JWT
+// C 需要内嵌 jwt.RegisteredClaims；exp/jti/iat 等注册 claim 的填充规则与 GenerateToken 相同
+func GenerateTokenFor[C jwt.Claims](m *JWTManager, claims C) (string, error) {
+	b, err := json.Marshal(claims)
+	if err != nil {
+		return "", err
+	}
+	// UseNumber 保留整数精度，避免 int64 经过 float64 丢失
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.UseNumber()
+	var mc jwt.MapClaims
+	if err := dec.Decode(&mc); err != nil {
+		return "", fmt.Errorf("claims must encode to a JSON object: %w", err)
+	}
+	return m.GenerateToken(mc)
+}
+
+// ParseTokenAs 解析 JWT 并解码为自定义 claims 结构体
+// 数字字段按结构体声明的类型解码（如 int64），不再是 float64
+// 若 *C 或 C 实现了 ClaimsValidator，会在解码后调用其 Validate
+func ParseTokenAs[C jwt.Claims](m *JWTManager, tokenStr string) (C, error) {
+	var claims C
+	token, err := m.parse(tokenStr)
+	if err != nil {
+		return claims, err
+	}
+	if err := checkSessionToken(token.Claims.(jwt.MapClaims)); err != nil {
+		return claims, err
+	}
+
+	parts := strings.Split(token.Raw, ".")
+	if len(parts) != 3 {
+		return claims, asTokenError(ErrTokenMalformed)
+	}
+	payload, err := jwt.NewParser().DecodeSegment(parts[1])
+	if err != nil {
+		return claims, asTokenError(ErrTokenMalformed)
+	}
+	if err := json.Unmarshal(payload, &claims); err != nil {
+		return claims, &TokenError{Kind: ErrInvalidClaims, Err: err}
+	}
+
+	var v interface{} = &claims
+	if _, ok := v.(ClaimsValidator); !ok {
+		v = claims
+	}
+	if cv, ok := v.(ClaimsValidator); ok {
+		if err := cv.Validate(); err != nil {
+			return claims, &TokenError{Kind: ErrInvalidClaims, Err: err}
+		}
+	}
+	return claims, nil
+}
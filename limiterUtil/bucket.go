@@ -3,6 +3,29 @@ package limiterUtil
 import (
 	"sync"
 	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+)
+
+// TokenSource 拿取/状态接口，Bucket、FixedWindow、SlidingWindowLog、SlidingWindowCounter、
+// DistributedBucket 都实现了该接口，可通过 NewDualBucketWith / NewTripleBucketWith 用作稳定桶与突发桶
+type TokenSource interface {
+	TryTake(count float64) bool // 尝试拿 count 个令牌
+	Tokens() float64            // 当前可用量
+	Capacity() float64          // 最大可用量
+}
+
+// 可选能力：支持时 TripleBucket 与 HTTP 中间件会使用
+type (
+	clockSetter interface {
+		SetClock(c clockutil.Clock)
+	}
+	waitEstimator interface {
+		TimeToTokens(n float64) time.Duration
+	}
+	refunder interface {
+		giveBack(count float64)
+	}
 )
 
 type Bucket struct {
@@ -10,6 +33,7 @@ type Bucket struct {
 	tokens     float64   // 当前令牌数（可为小数，支持浮点速率）
 	rate       float64   // 每秒生成令牌数（可以是小数）
 	lastUpdate time.Time // 上次更新时间
+	clock      clockutil.Clock
 	mu         sync.Mutex
 }
 
@@ -25,9 +49,19 @@ func NewBucket(capacity, rate float64) *Bucket {
 		tokens:     capacity,
 		rate:       rate,
 		lastUpdate: time.Now(),
+		clock:      clockutil.Real,
 	}
 }
 
+// SetClock 替换时间来源（线程安全），测试中可传入 FakeClock
+// 切换时钟会以新时钟的当前时间作为补充起点，已有令牌数不变
+func (b *Bucket) SetClock(c clockutil.Clock) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.clock = clockutil.OrReal(c)
+	b.lastUpdate = b.clock.Now()
+}
+
 // refillLocked 假设已经持有 mu
 func (b *Bucket) refillLocked(now time.Time) {
 	if b.rate <= 0 {
@@ -48,7 +82,7 @@ func (b *Bucket) refillLocked(now time.Time) {
 // Refill 手动补令牌（线程安全）
 func (b *Bucket) Refill() {
 	b.mu.Lock()
-	now := time.Now()
+	now := b.clock.Now()
 	b.refillLocked(now)
 	b.mu.Unlock()
 }
@@ -62,7 +96,7 @@ func (b *Bucket) TryTake(count float64) bool {
 	}
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	now := time.Now()
+	now := b.clock.Now()
 	b.refillLocked(now)
 	if b.tokens >= count {
 		b.tokens -= count
@@ -71,6 +105,17 @@ func (b *Bucket) TryTake(count float64) bool {
 	return false
 }
 
+// giveBack 归还拿到后未使用的令牌（不超过容量）
+func (b *Bucket) giveBack(count float64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked(b.clock.Now())
+	b.tokens += count
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
 // TakeOne 便捷：拿1个
 func (b *Bucket) TakeOne() bool {
 	return b.TryTake(1.0)
@@ -80,7 +125,7 @@ func (b *Bucket) TakeOne() bool {
 func (b *Bucket) Tokens() float64 {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	now := time.Now()
+	now := b.clock.Now()
 	b.refillLocked(now)
 	return b.tokens
 }
@@ -90,11 +135,32 @@ func (b *Bucket) Capacity() float64 {
 	return b.capacity
 }
 
+// Rate 返回每秒生成令牌数
+func (b *Bucket) Rate() float64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.rate
+}
+
+// TimeToTokens 距离桶内至少有 n 个令牌还需多久，速率为 0 时返回 -1 表示不会补足
+func (b *Bucket) TimeToTokens(n float64) time.Duration {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.refillLocked(b.clock.Now())
+	if b.tokens >= n {
+		return 0
+	}
+	if b.rate <= 0 {
+		return -1
+	}
+	return time.Duration((n - b.tokens) / b.rate * float64(time.Second))
+}
+
 // SetRate 修改生成速率（线程安全）
 func (b *Bucket) SetRate(rate float64) {
 	b.mu.Lock()
 	defer b.mu.Unlock()
-	b.refillLocked(time.Now())
+	b.refillLocked(b.clock.Now())
 	b.rate = rate
 }
 
@@ -0,0 +1,146 @@
+package limiterUtil
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+)
+
+// DistributedBucket 通过 Store 在多个副本间共享的令牌桶
+// 存储出错后的 RetryInterval 内改用本地 Fallback 桶，避免后端故障时全部拒绝或反复超时
+type DistributedBucket struct {
+	Store         Store
+	Key           string
+	Timeout       time.Duration // 单次存储调用超时，默认 100ms
+	RetryInterval time.Duration // 出错后多久再尝试存储，默认 1s
+	Fallback      *Bucket       // 存储不可用时使用的本地桶，为 nil 时直接放行
+
+	capacity  float64
+	rate      float64
+	clock     clockutil.Clock
+	mu        sync.Mutex
+	remaining float64
+	downUntil time.Time
+	lastErr   error
+}
+
+// NewDistributedBucket 默认的 Fallback 为同样配额的本地桶（按副本数均分更精确，可自行替换）
+func NewDistributedBucket(store Store, key string, capacity, rate float64) *DistributedBucket {
+	return &DistributedBucket{
+		Store:         store,
+		Key:           key,
+		Timeout:       100 * time.Millisecond,
+		RetryInterval: time.Second,
+		Fallback:      NewBucket(capacity, rate),
+		capacity:      capacity,
+		rate:          rate,
+		clock:         clockutil.Real,
+		remaining:     capacity,
+	}
+}
+
+// SetClock 替换时间来源（只影响故障退避与 Fallback 桶）
+func (d *DistributedBucket) SetClock(c clockutil.Clock) {
+	d.mu.Lock()
+	d.clock = clockutil.OrReal(c)
+	d.mu.Unlock()
+	if d.Fallback != nil {
+		d.Fallback.SetClock(c)
+	}
+}
+
+// TryTake 从集群配额中拿 n 个令牌
+func (d *DistributedBucket) TryTake(n float64) bool {
+	return d.TryTakeContext(context.Background(), n)
+}
+
+// TryTakeContext 同 TryTake，可传入请求的 ctx
+func (d *DistributedBucket) TryTakeContext(ctx context.Context, n float64) bool {
+	if n <= 0 {
+		return true
+	}
+	d.mu.Lock()
+	down := d.clock.Now().Before(d.downUntil)
+	d.mu.Unlock()
+	if down {
+		return d.fallbackTake(n)
+	}
+
+	timeout := d.Timeout
+	if timeout <= 0 {
+		timeout = 100 * time.Millisecond
+	}
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	ok, remaining, err := d.Store.TakeTokens(ctx, d.Key, d.capacity, d.rate, n)
+
+	d.mu.Lock()
+	if err != nil {
+		retry := d.RetryInterval
+		if retry <= 0 {
+			retry = time.Second
+		}
+		d.lastErr = err
+		d.downUntil = d.clock.Now().Add(retry)
+		d.mu.Unlock()
+		return d.fallbackTake(n)
+	}
+	d.lastErr = nil
+	d.remaining = remaining
+	d.mu.Unlock()
+	return ok
+}
+
+func (d *DistributedBucket) fallbackTake(n float64) bool {
+	if d.Fallback == nil {
+		return true
+	}
+	return d.Fallback.TryTake(n)
+}
+
+// Tokens 最近一次从存储读到的剩余令牌；降级期间返回本地桶的令牌
+func (d *DistributedBucket) Tokens() float64 {
+	if d.Degraded() && d.Fallback != nil {
+		return d.Fallback.Tokens()
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.remaining
+}
+
+// Capacity 集群配额容量
+func (d *DistributedBucket) Capacity() float64 {
+	return d.capacity
+}
+
+// TimeToTokens 按最近一次读到的剩余令牌与速率估算，速率为 0 时返回 -1；降级期间按本地桶估算
+func (d *DistributedBucket) TimeToTokens(n float64) time.Duration {
+	if d.Degraded() && d.Fallback != nil {
+		return d.Fallback.TimeToTokens(n)
+	}
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	if d.remaining >= n {
+		return 0
+	}
+	if d.rate <= 0 {
+		return -1
+	}
+	return time.Duration((n - d.remaining) / d.rate * float64(time.Second))
+}
+
+// Degraded 当前是否因存储故障使用本地桶
+func (d *DistributedBucket) Degraded() bool {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.clock.Now().Before(d.downUntil)
+}
+
+// LastError 最近一次存储错误，恢复后为 nil
+func (d *DistributedBucket) LastError() error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+	return d.lastErr
+}
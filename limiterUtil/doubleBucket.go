@@ -1,8 +1,11 @@
 package limiterUtil
 
 type DualBucket struct {
-	Stable *Bucket
+	Stable *Bucket // 使用 NewDualBucketWith 传入非 Bucket 来源时为 nil
 	Burst  *Bucket
+
+	stableSrc TokenSource // NewDualBucketWith 传入的非 Bucket 来源
+	burstSrc  TokenSource
 }
 
 func NewDualBucket(stableCap, stableRate, burstCap, burstRate float64) *DualBucket {
@@ -12,15 +15,50 @@ func NewDualBucket(stableCap, stableRate, burstCap, burstRate float64) *DualBuck
 	}
 }
 
+// NewDualBucketWith 使用任意 TokenSource（例如滑动窗口）作为稳定桶与突发桶
+// 传入 *Bucket 时仍可通过 Stable / Burst 字段访问
+func NewDualBucketWith(stable, burst TokenSource) *DualBucket {
+	d := &DualBucket{}
+	d.Stable, d.stableSrc = splitSource(stable)
+	d.Burst, d.burstSrc = splitSource(burst)
+	return d
+}
+
+// splitSource *Bucket 放入导出字段，其它 TokenSource 单独保存
+func splitSource(s TokenSource) (*Bucket, TokenSource) {
+	if b, ok := s.(*Bucket); ok {
+		return b, nil
+	}
+	return nil, s
+}
+
+// pickSource 优先使用自定义来源
+func pickSource(src TokenSource, b *Bucket) TokenSource {
+	if src != nil {
+		return src
+	}
+	return b
+}
+
+// StableSource 实际使用的稳定来源
+func (d *DualBucket) StableSource() TokenSource {
+	return pickSource(d.stableSrc, d.Stable)
+}
+
+// BurstSource 实际使用的突发来源
+func (d *DualBucket) BurstSource() TokenSource {
+	return pickSource(d.burstSrc, d.Burst)
+}
+
 // TryTake 优先稳定桶，稳定桶不足时再尝试突发桶
 // 返回 true 表示成功拿到 token
 func (d *DualBucket) TryTake() bool {
 	// 先试 Stable
-	if d.Stable.TryTake(1.0) {
+	if d.StableSource().TryTake(1.0) {
 		return true
 	}
 	// 再试 Burst
-	if d.Burst.TryTake(1.0) {
+	if d.BurstSource().TryTake(1.0) {
 		return true
 	}
 	return false
@@ -28,5 +66,5 @@ func (d *DualBucket) TryTake() bool {
 
 // Status 返回当前两个桶的 token 状态
 func (d *DualBucket) Status() (stable float64, burst float64) {
-	return d.Stable.Tokens(), d.Burst.Tokens()
+	return d.StableSource().Tokens(), d.BurstSource().Tokens()
 }
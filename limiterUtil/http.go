@@ -0,0 +1,185 @@
+package limiterUtil
+
+import (
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ---------------------------
+// HTTP 限流中间件
+// ---------------------------
+
+// KeyFunc 从请求中提取限流 key，提取不到时返回 false
+// 按 JWT claim 限流可使用 jwtutil.ClaimKey
+type KeyFunc func(r *http.Request) (string, bool)
+
+// BucketSource 按 key 提供桶，KeyedLimiter 实现了该接口
+type BucketSource interface {
+	Bucket(key string) *TripleBucket
+}
+
+type sharedBucket struct{ tb *TripleBucket }
+
+func (s sharedBucket) Bucket(string) *TripleBucket { return s.tb }
+
+// SharedBucket 所有请求共用一个桶（全局限流）
+func SharedBucket(tb *TripleBucket) BucketSource {
+	return sharedBucket{tb: tb}
+}
+
+// ClientIPKey 以客户端 IP 为 key
+// 只有直连地址属于 trustedProxies（IP 或 CIDR）时才解析 X-Forwarded-For，
+// 从右向左跳过可信代理，取第一个不可信地址，防止客户端伪造
+func ClientIPKey(trustedProxies ...string) (KeyFunc, error) {
+	var nets []*net.IPNet
+	for _, p := range trustedProxies {
+		if !strings.Contains(p, "/") {
+			ip := net.ParseIP(p)
+			if ip == nil {
+				return nil, fmt.Errorf("invalid trusted proxy %q", p)
+			}
+			bits := 8 * net.IPv6len
+			if ip.To4() != nil {
+				ip, bits = ip.To4(), 8*net.IPv4len
+			}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(bits, bits)})
+			continue
+		}
+		_, n, err := net.ParseCIDR(p)
+		if err != nil {
+			return nil, fmt.Errorf("invalid trusted proxy %q: %w", p, err)
+		}
+		nets = append(nets, n)
+	}
+	trusted := func(ip net.IP) bool {
+		for _, n := range nets {
+			if n.Contains(ip) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(r *http.Request) (string, bool) {
+		host, _, err := net.SplitHostPort(r.RemoteAddr)
+		if err != nil {
+			host = r.RemoteAddr
+		}
+		ip := net.ParseIP(host)
+		if ip == nil {
+			return "", false
+		}
+		if !trusted(ip) {
+			return ip.String(), true
+		}
+		var hops []string
+		for _, h := range r.Header.Values("X-Forwarded-For") {
+			hops = append(hops, strings.Split(h, ",")...)
+		}
+		for i := len(hops) - 1; i >= 0; i-- {
+			hop := net.ParseIP(strings.TrimSpace(hops[i]))
+			if hop == nil {
+				// 无法解析的条目之前的内容都不可信，停在最后一个可信代理
+				break
+			}
+			ip = hop
+			if !trusted(hop) {
+				break
+			}
+		}
+		return ip.String(), true
+	}, nil
+}
+
+// HeaderKey 以请求头的值为 key，例如 X-API-Key
+func HeaderKey(name string) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		v := r.Header.Get(name)
+		return v, v != ""
+	}
+}
+
+// FirstKey 按顺序尝试多个 KeyFunc，例如先按用户、再按 IP
+func FirstKey(funcs ...KeyFunc) KeyFunc {
+	return func(r *http.Request) (string, bool) {
+		for _, f := range funcs {
+			if k, ok := f(r); ok {
+				return k, true
+			}
+		}
+		return "", false
+	}
+}
+
+type RateLimitConfig struct {
+	// Key 提取限流 key；为空或提取失败时落入共享的空 key 桶（不会放行）
+	Key KeyFunc
+	// OnLimited 被限流时的响应，为空时返回 429 纯文本；调用前已写好 Retry-After 等头
+	OnLimited func(w http.ResponseWriter, r *http.Request)
+}
+
+// RateLimitMiddleware 标准 net/http 限流中间件
+// 每个响应都带 RateLimit-Limit / RateLimit-Remaining / RateLimit-Reset，被拒绝时返回 429 与 Retry-After
+func RateLimitMiddleware(src BucketSource, cfg RateLimitConfig) func(http.Handler) http.Handler {
+	onLimited := cfg.OnLimited
+	if onLimited == nil {
+		onLimited = func(w http.ResponseWriter, r *http.Request) {
+			http.Error(w, http.StatusText(http.StatusTooManyRequests), http.StatusTooManyRequests)
+		}
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			var key string
+			if cfg.Key != nil {
+				key, _ = cfg.Key(r)
+			}
+			tb := src.Bucket(key)
+			allowed := tb.TryTake()
+			writeRateLimitHeaders(w.Header(), tb)
+			if !allowed {
+				if d := tb.RetryAfter(); d >= 0 {
+					w.Header().Set("Retry-After", strconv.Itoa(ceilSeconds(d)))
+				}
+				onLimited(w, r)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+// writeRateLimitHeaders 按两桶状态计算 IETF RateLimit 头
+// Limit 为两桶容量之和，Reset 为两桶补满所需时间（无法补满或无法估算的桶不计入）
+func writeRateLimitHeaders(h http.Header, tb *TripleBucket) {
+	limit, remaining := 0.0, 0.0
+	var reset time.Duration
+	for _, b := range []TokenSource{tb.StableSource(), tb.BurstSource()} {
+		limit += b.Capacity()
+		tokens := b.Tokens()
+		if tokens > 0 {
+			remaining += tokens
+		}
+		if d := timeToTokens(b, b.Capacity()); d > reset {
+			reset = d
+		}
+	}
+	if tb.IsRejected() {
+		remaining = 0
+		if d := tb.RetryAfter(); d > reset {
+			reset = d
+		}
+	}
+	h.Set("RateLimit-Limit", strconv.Itoa(int(limit)))
+	h.Set("RateLimit-Remaining", strconv.Itoa(int(math.Floor(remaining))))
+	h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(reset)))
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
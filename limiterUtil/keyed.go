@@ -0,0 +1,217 @@
+package limiterUtil
+
+import (
+	"container/list"
+	"sync"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/hashutil"
+)
+
+// ---------------------------
+// 按 key 限流（API key、用户 ID、客户端 IP 等）
+// ---------------------------
+
+type KeyedLimiterConfig struct {
+	Template        LimiterConfig            // 新 key 的桶与熔断配置（只使用 Stable*/Burst*/NewStable/NewBurst/FailThreshold/RejectDur/Clock）
+	Overrides       map[string]LimiterConfig // 指定 key 使用独立配置
+	Shards          int                      // 分片数，默认 16
+	MaxKeys         int                      // key 总数上限（按分片均分），超出时淘汰最久未访问的 key，0 表示不限
+	IdleTTL         time.Duration            // 超过该时间未访问的 key 会被清理，0 表示不清理
+	CleanupInterval time.Duration            // 后台清理周期，默认等于 IdleTTL
+}
+
+type keyedEntry struct {
+	key      string
+	bucket   *TripleBucket
+	lastSeen time.Time
+}
+
+type keyedShard struct {
+	mu    sync.Mutex
+	items map[string]*list.Element
+	lru   *list.List // 队头为最近访问
+}
+
+// KeyedLimiter 为每个 key 惰性创建独立的 TripleBucket
+// key 被淘汰后再次出现会得到一个全新的桶，MaxKeys 需大于活跃 key 数量
+type KeyedLimiter struct {
+	shards      []*keyedShard
+	cfg         KeyedLimiterConfig
+	clock       clockutil.Clock
+	maxPerShard int
+
+	overrideMu sync.RWMutex
+	overrides  map[string]LimiterConfig
+
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+func NewKeyedLimiter(cfg KeyedLimiterConfig) *KeyedLimiter {
+	if cfg.Shards <= 0 {
+		cfg.Shards = 16
+	}
+	k := &KeyedLimiter{
+		shards:    make([]*keyedShard, cfg.Shards),
+		cfg:       cfg,
+		clock:     clockutil.OrReal(cfg.Template.Clock),
+		overrides: make(map[string]LimiterConfig, len(cfg.Overrides)),
+		stopCh:    make(chan struct{}),
+	}
+	for i := range k.shards {
+		k.shards[i] = &keyedShard{items: make(map[string]*list.Element), lru: list.New()}
+	}
+	for key, c := range cfg.Overrides {
+		k.overrides[key] = c
+	}
+	if cfg.MaxKeys > 0 {
+		k.maxPerShard = (cfg.MaxKeys + cfg.Shards - 1) / cfg.Shards
+	}
+	if cfg.IdleTTL > 0 {
+		go k.cleanupLoop()
+	}
+	return k
+}
+
+func (k *KeyedLimiter) shard(key string) *keyedShard {
+	return k.shards[hashutil.FNVHashIndex([]byte(key), len(k.shards))]
+}
+
+func (k *KeyedLimiter) newBucket(key string) *TripleBucket {
+	cfg := k.cfg.Template
+	k.overrideMu.RLock()
+	if c, ok := k.overrides[key]; ok {
+		cfg = c
+	}
+	k.overrideMu.RUnlock()
+	tb := cfg.newTripleBucket()
+	tb.SetClock(k.clock)
+	return tb
+}
+
+// Bucket 返回 key 对应的桶，不存在时按模板（或覆盖配置）创建
+func (k *KeyedLimiter) Bucket(key string) *TripleBucket {
+	s := k.shard(key)
+	now := k.clock.Now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if el, ok := s.items[key]; ok {
+		e := el.Value.(*keyedEntry)
+		if k.cfg.IdleTTL <= 0 || now.Sub(e.lastSeen) <= k.cfg.IdleTTL {
+			e.lastSeen = now
+			s.lru.MoveToFront(el)
+			return e.bucket
+		}
+		// 已闲置超时，按新 key 处理
+		s.lru.Remove(el)
+		delete(s.items, key)
+	}
+
+	s.evictIdleLocked(now, k.cfg.IdleTTL)
+	if k.maxPerShard > 0 {
+		for s.lru.Len() >= k.maxPerShard {
+			s.removeLocked(s.lru.Back())
+		}
+	}
+	e := &keyedEntry{key: key, bucket: k.newBucket(key), lastSeen: now}
+	s.items[key] = s.lru.PushFront(e)
+	return e.bucket
+}
+
+// Allow 对 key 尝试拿 1 个令牌
+func (k *KeyedLimiter) Allow(key string) bool {
+	return k.Bucket(key).TryTake()
+}
+
+// SetOverride 为 key 设置独立配置，已存在的桶会被丢弃并在下次访问时重建
+func (k *KeyedLimiter) SetOverride(key string, cfg LimiterConfig) {
+	k.overrideMu.Lock()
+	k.overrides[key] = cfg
+	k.overrideMu.Unlock()
+	k.Remove(key)
+}
+
+// RemoveOverride 删除 key 的独立配置，恢复使用模板
+func (k *KeyedLimiter) RemoveOverride(key string) {
+	k.overrideMu.Lock()
+	delete(k.overrides, key)
+	k.overrideMu.Unlock()
+	k.Remove(key)
+}
+
+// Remove 丢弃 key 的桶
+func (k *KeyedLimiter) Remove(key string) {
+	s := k.shard(key)
+	s.mu.Lock()
+	if el, ok := s.items[key]; ok {
+		s.removeLocked(el)
+	}
+	s.mu.Unlock()
+}
+
+// Len 当前跟踪的 key 数量
+func (k *KeyedLimiter) Len() int {
+	n := 0
+	for _, s := range k.shards {
+		s.mu.Lock()
+		n += len(s.items)
+		s.mu.Unlock()
+	}
+	return n
+}
+
+// Cleanup 立即清理所有分片中闲置超时的 key
+func (k *KeyedLimiter) Cleanup() {
+	if k.cfg.IdleTTL <= 0 {
+		return
+	}
+	now := k.clock.Now()
+	for _, s := range k.shards {
+		s.mu.Lock()
+		s.evictIdleLocked(now, k.cfg.IdleTTL)
+		s.mu.Unlock()
+	}
+}
+
+// Stop 停止后台清理
+func (k *KeyedLimiter) Stop() {
+	k.stopOnce.Do(func() { close(k.stopCh) })
+}
+
+func (k *KeyedLimiter) cleanupLoop() {
+	interval := k.cfg.CleanupInterval
+	if interval <= 0 {
+		interval = k.cfg.IdleTTL
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-k.stopCh:
+			return
+		case <-ticker.C:
+			k.Cleanup()
+		}
+	}
+}
+
+// evictIdleLocked 从 LRU 队尾开始删除闲置超时的 key
+func (s *keyedShard) evictIdleLocked(now time.Time, ttl time.Duration) {
+	if ttl <= 0 {
+		return
+	}
+	for el := s.lru.Back(); el != nil; el = s.lru.Back() {
+		if now.Sub(el.Value.(*keyedEntry).lastSeen) <= ttl {
+			return
+		}
+		s.removeLocked(el)
+	}
+}
+
+func (s *keyedShard) removeLocked(el *list.Element) {
+	s.lru.Remove(el)
+	delete(s.items, el.Value.(*keyedEntry).key)
+}
@@ -4,6 +4,8 @@ import (
 	"context"
 	"sync"
 	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
 )
 
 // ---------------------------
@@ -32,6 +34,30 @@ type LimiterConfig struct {
 	WorkerInterval   time.Duration // worker 尝试从队列拉取的间隔
 	QueueItemTTL     time.Duration // 队列项在队列中的最大等待时间
 	TokenWaitTimeout time.Duration // worker 等待令牌的最大时间（独立于队列TTL）
+
+	Clock clockutil.Clock    // 时间来源（令牌补充、熔断、队列过期），为 nil 时使用系统时钟
+	Quota *DistributedBucket // 集群共享配额，本地三桶放行后再扣除，为 nil 时只做本地限流
+
+	// 自定义稳定桶/突发桶（例如滑动窗口），设置后忽略对应的 Cap/Rate
+	// 使用工厂函数，KeyedLimiter 才能为每个 key 创建独立实例
+	NewStable func() TokenSource
+	NewBurst  func() TokenSource
+}
+
+// newTripleBucket 按配置创建三桶
+func (cfg LimiterConfig) newTripleBucket() *TripleBucket {
+	var stable, burst TokenSource
+	if cfg.NewStable != nil {
+		stable = cfg.NewStable()
+	} else {
+		stable = NewBucket(cfg.StableCap, cfg.StableRate)
+	}
+	if cfg.NewBurst != nil {
+		burst = cfg.NewBurst()
+	} else {
+		burst = NewBucket(cfg.BurstCap, cfg.BurstRate)
+	}
+	return NewTripleBucketWith(stable, burst, cfg.FailThreshold, cfg.RejectDur)
 }
 
 // ---------------------------
@@ -55,11 +81,15 @@ func NewLimiter[T any](cfg LimiterConfig) *Limiter[T] {
 		cfg.TokenWaitTimeout = 30 * time.Second
 	}
 
-	tb := NewTripleBucket(cfg.StableCap, cfg.StableRate, cfg.BurstCap, cfg.BurstRate, cfg.FailThreshold, cfg.RejectDur)
+	tb := cfg.newTripleBucket()
 
 	q := NewQueue[T]()
 	q.SetMaxLen(cfg.QueueMaxLen)
 	q.SetCleanupInterval(cfg.QueueCleanup)
+	if cfg.Clock != nil {
+		tb.SetClock(cfg.Clock)
+		q.SetClock(cfg.Clock)
+	}
 
 	return &Limiter[T]{
 		triple:    tb,
@@ -102,7 +132,7 @@ func (l *Limiter[T]) Submit(payload T) State {
 		return StateDiscarded
 	}
 
-	if l.triple.TryTake() {
+	if ok, _ := l.take(); ok {
 		if l.onProcess != nil {
 			go l.onProcess(payload)
 		}
@@ -115,6 +145,33 @@ func (l *Limiter[T]) Submit(payload T) State {
 	return StateQueued
 }
 
+// 集群配额拒绝后 worker 最长的重试间隔
+const quotaRetryMax = time.Second
+
+// ---------------------------
+// 拿令牌：先过本地三桶（保护后端），再扣集群配额
+// 集群配额拒绝时本地令牌没有被使用，归还并计一次失败（连续拒绝会触发熔断）
+// 返回的 wait 为配额预计补足的时间，-1 表示无法估算
+// ---------------------------
+func (l *Limiter[T]) take() (ok bool, wait time.Duration) {
+	tb := l.triple
+	if tb.IsRejected() {
+		return false, 0
+	}
+	src := tb.take()
+	if src == nil {
+		tb.recordFailure()
+		return false, 0
+	}
+	if l.cfg.Quota != nil && !l.cfg.Quota.TryTake(1) {
+		tb.giveBack(src)
+		tb.recordFailure()
+		return false, l.cfg.Quota.TimeToTokens(1)
+	}
+	tb.recordSuccess()
+	return true, 0
+}
+
 // ---------------------------
 // worker 循环（修复版）
 // ---------------------------
@@ -176,18 +233,27 @@ func (l *Limiter[T]) workerLoop() {
 			}
 
 			// 尝试获取令牌
-			if l.triple.TryTake() {
+			ok, wait := l.take()
+			if ok {
 				if l.onProcess != nil {
 					go l.onProcess(item)
 				}
 				break
 			}
 
-			// 使用更短的重试间隔提高吞吐量
+			// 本地桶不足时用更短的重试间隔提高吞吐量；
+			// 集群配额不足时按配额补足时间退避，避免每 10ms 访问一次存储
+			retry := 10 * time.Millisecond
+			if wait < 0 || wait > quotaRetryMax {
+				wait = quotaRetryMax
+			}
+			if wait > retry {
+				retry = wait
+			}
 			select {
 			case <-l.stopCh:
 				return
-			case <-time.After(10 * time.Millisecond):
+			case <-time.After(retry):
 				// 继续重试
 			}
 		}
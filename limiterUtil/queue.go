@@ -5,6 +5,8 @@ import (
 	"errors"
 	"sync"
 	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
 )
 
 // 泛型队列项
@@ -19,12 +21,13 @@ type Queue[T any] struct {
 	lock            sync.Mutex
 	maxLen          int
 	cleanupInterval time.Duration
+	clock           clockutil.Clock
 	stopCh          chan struct{}
 }
 
 // 构造函数
 func NewQueue[T any]() *Queue[T] {
-	q := &Queue[T]{stopCh: make(chan struct{})}
+	q := &Queue[T]{clock: clockutil.Real, stopCh: make(chan struct{})}
 	go q.cleanupLoop()
 	return q
 }
@@ -36,7 +39,26 @@ func (q *Queue[T]) SetMaxLen(max int) {
 
 // 设置队列清理周期
 func (q *Queue[T]) SetCleanupInterval(d time.Duration) {
+	q.lock.Lock()
 	q.cleanupInterval = d
+	q.lock.Unlock()
+}
+
+// 读取清理周期，未设置时默认 1 秒
+func (q *Queue[T]) cleanupPeriod() time.Duration {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.cleanupInterval <= 0 {
+		return time.Second
+	}
+	return q.cleanupInterval
+}
+
+// 设置时间来源（决定队列项何时过期），测试中可传入 FakeClock
+func (q *Queue[T]) SetClock(c clockutil.Clock) {
+	q.lock.Lock()
+	q.clock = clockutil.OrReal(c)
+	q.lock.Unlock()
 }
 
 // 入队操作（失败返回 error）
@@ -48,7 +70,7 @@ func (q *Queue[T]) Enqueue(payload T, ttl time.Duration) error {
 		return errors.New("queue full")
 	}
 
-	q.items = append(q.items, queueItem[T]{payload: payload, expireAt: time.Now().Add(ttl)})
+	q.items = append(q.items, queueItem[T]{payload: payload, expireAt: q.clock.Now().Add(ttl)})
 	return nil
 }
 
@@ -63,7 +85,7 @@ func (q *Queue[T]) Dequeue(ctx context.Context) (T, bool) {
 		}
 
 		item := q.items[0]
-		if time.Now().After(item.expireAt) {
+		if q.clock.Now().After(item.expireAt) {
 			// 丢弃过期项
 			q.items = q.items[1:]
 			q.lock.Unlock()
@@ -78,7 +100,8 @@ func (q *Queue[T]) Dequeue(ctx context.Context) (T, bool) {
 
 // 后台清理过期队列项
 func (q *Queue[T]) cleanupLoop() {
-	ticker := time.NewTicker(q.cleanupInterval)
+	period := q.cleanupPeriod()
+	ticker := time.NewTicker(period)
 	defer ticker.Stop()
 
 	for {
@@ -86,8 +109,8 @@ func (q *Queue[T]) cleanupLoop() {
 		case <-q.stopCh:
 			return
 		case <-ticker.C:
-			now := time.Now()
 			q.lock.Lock()
+			now := q.clock.Now()
 			filtered := q.items[:0]
 			for _, item := range q.items {
 				if item.expireAt.After(now) {
@@ -96,6 +119,12 @@ func (q *Queue[T]) cleanupLoop() {
 			}
 			q.items = filtered
 			q.lock.Unlock()
+
+			// 构造后才调用 SetCleanupInterval 时在这里生效
+			if d := q.cleanupPeriod(); d != period {
+				period = d
+				ticker.Reset(period)
+			}
 		}
 	}
 }
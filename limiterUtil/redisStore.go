@@ -0,0 +1,117 @@
+package limiterUtil
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+)
+
+// 令牌桶脚本：使用 Redis 服务器时间，避免各副本时钟不一致
+// KEYS[1] 桶 key；ARGV: capacity, rate, n, ttl(ms)
+var takeTokensScript = redis.NewScript(`
+local capacity = tonumber(ARGV[1])
+local rate = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+local t = redis.call('TIME')
+local now = tonumber(t[1]) + tonumber(t[2]) / 1000000
+
+local state = redis.call('HMGET', KEYS[1], 'tokens', 'ts')
+local tokens = tonumber(state[1])
+local ts = tonumber(state[2])
+if tokens == nil or ts == nil then
+	tokens = capacity
+	ts = now
+end
+if now > ts then
+	tokens = math.min(capacity, tokens + (now - ts) * rate)
+	ts = now
+end
+
+local ok = 0
+if tokens >= n then
+	tokens = tokens - n
+	ok = 1
+end
+redis.call('HSET', KEYS[1], 'tokens', tostring(tokens), 'ts', tostring(ts))
+redis.call('PEXPIRE', KEYS[1], ARGV[4])
+return {ok, tostring(tokens)}
+`)
+
+// 固定窗口脚本：KEYS[1] 当前窗口的 key（由调用方按本机时钟计算，保证 Redis Cluster 下 key 都经 KEYS 声明）
+// ARGV: limit, window(ms), n
+var takeWindowScript = redis.NewScript(`
+local limit = tonumber(ARGV[1])
+local window = tonumber(ARGV[2])
+local n = tonumber(ARGV[3])
+
+local count = tonumber(redis.call('GET', KEYS[1]) or '0')
+if count + n > limit then
+	return {0, count}
+end
+count = redis.call('INCRBY', KEYS[1], n)
+if count == n then
+	redis.call('PEXPIRE', KEYS[1], window)
+end
+return {1, count}
+`)
+
+// RedisStore 基于 Redis 的 Store，每个操作由一个 Lua 脚本原子完成
+// 令牌桶使用 Redis 服务器时间；固定窗口的 key 按本机时钟对齐，各副本时钟偏差会让窗口边界略有出入
+type RedisStore struct {
+	client redis.Scripter
+	prefix string
+	clock  clockutil.Clock
+	mu     sync.Mutex
+}
+
+// NewRedisStore prefix 会加在所有 key 之前，例如 "ratelimit:"
+func NewRedisStore(client redis.Scripter, prefix string) *RedisStore {
+	return &RedisStore{client: client, prefix: prefix, clock: clockutil.Real}
+}
+
+// SetClock 替换计算窗口 key 的时间来源，测试中可传入 FakeClock
+func (s *RedisStore) SetClock(c clockutil.Clock) {
+	s.mu.Lock()
+	s.clock = clockutil.OrReal(c)
+	s.mu.Unlock()
+}
+
+func (s *RedisStore) TakeTokens(ctx context.Context, key string, capacity, rate, n float64) (bool, float64, error) {
+	ttl := bucketTTL(capacity, rate).Milliseconds()
+	res, err := takeTokensScript.Run(ctx, s.client, []string{s.prefix + key},
+		formatFloat(capacity), formatFloat(rate), formatFloat(n), ttl).Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	ok, _ := res[0].(int64)
+	str, _ := res[1].(string)
+	remaining, err := strconv.ParseFloat(str, 64)
+	if err != nil {
+		return false, 0, err
+	}
+	return ok == 1, remaining, nil
+}
+
+func (s *RedisStore) TakeWindow(ctx context.Context, key string, limit int64, window time.Duration, n int64) (bool, int64, error) {
+	ms := window.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	s.mu.Lock()
+	now := s.clock.Now()
+	s.mu.Unlock()
+	wkey, _ := windowKey(s.prefix+key, now, window)
+	res, err := takeWindowScript.Run(ctx, s.client, []string{wkey}, limit, ms, n).Int64Slice()
+	if err != nil {
+		return false, 0, err
+	}
+	return res[0] == 1, res[1], nil
+}
+
+func formatFloat(f float64) string {
+	return strconv.FormatFloat(f, 'f', -1, 64)
+}
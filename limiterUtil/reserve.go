@@ -0,0 +1,127 @@
+package limiterUtil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+)
+
+var ErrExceedsCapacity = errors.New("requested tokens exceed bucket capacity")
+
+// Reservation 预约的令牌，到 Delay() 之后才应执行对应操作
+type Reservation struct {
+	bucket    *Bucket
+	tokens    float64
+	timeToAct time.Time
+	ok        bool
+	mu        sync.Mutex
+	canceled  bool
+}
+
+// OK 预约是否成功；请求量超过容量或速率为 0 且令牌不足时为 false
+func (r *Reservation) OK() bool {
+	return r.ok
+}
+
+// Delay 距离可以执行还需等待的时间，0 表示立即可执行
+func (r *Reservation) Delay() time.Duration {
+	if !r.ok {
+		return 0
+	}
+	d := r.timeToAct.Sub(r.bucket.now())
+	if d < 0 {
+		return 0
+	}
+	return d
+}
+
+// Cancel 放弃预约，在执行时间之前取消会把令牌还给桶
+func (r *Reservation) Cancel() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if !r.ok || r.canceled {
+		return
+	}
+	r.canceled = true
+	b := r.bucket
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	if !now.Before(r.timeToAct) {
+		// 已经到执行时间，视为令牌已被使用
+		return
+	}
+	b.refillLocked(now)
+	b.tokens += r.tokens
+	if b.tokens > b.capacity {
+		b.tokens = b.capacity
+	}
+}
+
+// now 读取桶的时钟（线程安全）
+func (b *Bucket) now() time.Time {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.clock.Now()
+}
+
+// Reserve 立即扣除 n 个令牌（允许透支），返回需要等待的时间
+// 透支期间 Tokens() 可能为负，后续 TryTake 会失败直到补足
+func (b *Bucket) Reserve(n float64) *Reservation {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	now := b.clock.Now()
+	r := &Reservation{bucket: b, tokens: n, timeToAct: now}
+	if n <= 0 {
+		r.ok = true
+		return r
+	}
+	if n > b.capacity {
+		return r
+	}
+	b.refillLocked(now)
+	if b.tokens < n && b.rate <= 0 {
+		return r
+	}
+	b.tokens -= n
+	if b.tokens < 0 {
+		// 按速率直接算出补足透支需要的时间，无需轮询
+		wait := time.Duration(-b.tokens / b.rate * float64(time.Second))
+		r.timeToAct = now.Add(wait)
+	}
+	r.ok = true
+	return r
+}
+
+// Wait 阻塞直到拿到 n 个令牌或 ctx 结束
+// 若 ctx 的截止时间早于可执行时间，会立即返回错误并归还令牌
+// 等待使用真实计时器，注入 FakeClock 时延迟按假时钟计算
+func (b *Bucket) Wait(ctx context.Context, n float64) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	r := b.Reserve(n)
+	if !r.OK() {
+		return fmt.Errorf("%w: %v > %v", ErrExceedsCapacity, n, b.Capacity())
+	}
+	delay := r.Delay()
+	if delay == 0 {
+		return nil
+	}
+	if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) < delay {
+		r.Cancel()
+		return fmt.Errorf("%w: need to wait %v", context.DeadlineExceeded, delay)
+	}
+
+	t := time.NewTimer(delay)
+	defer t.Stop()
+	select {
+	case <-t.C:
+		return nil
+	case <-ctx.Done():
+		r.Cancel()
+		return ctx.Err()
+	}
+}
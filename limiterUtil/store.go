@@ -0,0 +1,140 @@
+package limiterUtil
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+)
+
+// ---------------------------
+// 共享状态存储
+// ---------------------------
+
+// Store 限流状态存储，多个副本共享同一个 Store 即可实现集群级配额
+// 实现必须保证每个操作原子完成
+type Store interface {
+	// TakeTokens 令牌桶：按 capacity/rate 惰性补充后尝试扣除 n 个令牌，返回是否成功与剩余令牌
+	TakeTokens(ctx context.Context, key string, capacity, rate, n float64) (ok bool, remaining float64, err error)
+	// TakeWindow 固定窗口：当前窗口计数加 n 后不超过 limit 时计入并返回 true，返回值 count 为计数
+	// 库内的 TokenSource 目前不使用它（计数无法归还，不适合配合 Limiter），供调用方直接做按窗口计数的集群配额
+	TakeWindow(ctx context.Context, key string, limit int64, window time.Duration, n int64) (ok bool, count int64, err error)
+}
+
+type memoryBucketState struct {
+	tokens   float64
+	last     time.Time
+	expireAt time.Time
+}
+
+type memoryWindowState struct {
+	count    int64
+	expireAt time.Time
+}
+
+// MemoryStore 进程内 Store，适合单实例部署与测试
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*memoryBucketState
+	windows   map[string]*memoryWindowState
+	clock     clockutil.Clock
+	lastPurge time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		buckets: make(map[string]*memoryBucketState),
+		windows: make(map[string]*memoryWindowState),
+		clock:   clockutil.Real,
+	}
+}
+
+// SetClock 替换时间来源，测试中可传入 FakeClock
+func (s *MemoryStore) SetClock(c clockutil.Clock) {
+	s.mu.Lock()
+	s.clock = clockutil.OrReal(c)
+	s.mu.Unlock()
+}
+
+// purgeLocked 每分钟最多清理一次过期状态
+func (s *MemoryStore) purgeLocked(now time.Time) {
+	if now.Sub(s.lastPurge) < time.Minute {
+		return
+	}
+	s.lastPurge = now
+	for k, b := range s.buckets {
+		if now.After(b.expireAt) {
+			delete(s.buckets, k)
+		}
+	}
+	for k, w := range s.windows {
+		if now.After(w.expireAt) {
+			delete(s.windows, k)
+		}
+	}
+}
+
+func (s *MemoryStore) TakeTokens(ctx context.Context, key string, capacity, rate, n float64) (bool, float64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.purgeLocked(now)
+
+	b, ok := s.buckets[key]
+	if !ok || now.After(b.expireAt) {
+		b = &memoryBucketState{tokens: capacity, last: now}
+		s.buckets[key] = b
+	}
+	if elapsed := now.Sub(b.last).Seconds(); elapsed > 0 {
+		b.tokens += elapsed * rate
+		if b.tokens > capacity {
+			b.tokens = capacity
+		}
+		b.last = now
+	}
+	allowed := b.tokens >= n
+	if allowed {
+		b.tokens -= n
+	}
+	b.expireAt = now.Add(bucketTTL(capacity, rate))
+	return allowed, b.tokens, nil
+}
+
+func (s *MemoryStore) TakeWindow(ctx context.Context, key string, limit int64, window time.Duration, n int64) (bool, int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.purgeLocked(now)
+
+	wkey, expireAt := windowKey(key, now, window)
+	w, ok := s.windows[wkey]
+	if !ok {
+		w = &memoryWindowState{expireAt: expireAt}
+		s.windows[wkey] = w
+	}
+	if w.count+n > limit {
+		return false, w.count, nil
+	}
+	w.count += n
+	return true, w.count, nil
+}
+
+// bucketTTL 桶补满后状态即可丢弃，额外保留 1 秒
+func bucketTTL(capacity, rate float64) time.Duration {
+	if rate <= 0 {
+		return 24 * time.Hour
+	}
+	return time.Duration(capacity/rate*float64(time.Second)) + time.Second
+}
+
+// windowKey 返回 now 所在窗口的 key 与窗口结束时间，窗口按 Unix 毫秒对齐（MemoryStore 与 RedisStore 共用）
+func windowKey(key string, now time.Time, window time.Duration) (string, time.Time) {
+	ms := window.Milliseconds()
+	if ms <= 0 {
+		ms = 1
+	}
+	idx := now.UnixMilli() / ms
+	return key + ":" + strconv.FormatInt(idx, 10), time.UnixMilli((idx + 1) * ms)
+}
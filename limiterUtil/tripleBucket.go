@@ -3,72 +3,149 @@ package limiterUtil
 import (
 	"sync"
 	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
 )
 
 type TripleBucket struct {
-	Stable *Bucket
+	Stable *Bucket // 使用 NewTripleBucketWith 传入非 Bucket 来源时为 nil
 	Burst  *Bucket
 
+	stableSrc TokenSource // NewTripleBucketWith 传入的非 Bucket 来源
+	burstSrc  TokenSource
+
 	// 熔断策略
 	failCount     int           // 连续失败计数
 	failThreshold int           // 超过这个阈值触发 reject
 	rejectUntil   time.Time     // 拒绝截止时间（在此之前所有请求被直接拒绝）
 	rejectDur     time.Duration // 冷却时长
+	clock         clockutil.Clock
 	mu            sync.Mutex
 }
 
 func NewTripleBucket(stableCap, stableRate, burstCap, burstRate float64, failThreshold int, rejectDur time.Duration) *TripleBucket {
-	return &TripleBucket{
-		Stable:        NewBucket(stableCap, stableRate),
-		Burst:         NewBucket(burstCap, burstRate),
+	return NewTripleBucketWith(NewBucket(stableCap, stableRate), NewBucket(burstCap, burstRate), failThreshold, rejectDur)
+}
+
+// NewTripleBucketWith 使用任意 TokenSource（例如滑动窗口）作为稳定桶与突发桶
+// 传入 *Bucket 时仍可通过 Stable / Burst 字段访问
+func NewTripleBucketWith(stable, burst TokenSource, failThreshold int, rejectDur time.Duration) *TripleBucket {
+	t := &TripleBucket{
 		failThreshold: failThreshold,
 		rejectDur:     rejectDur,
+		clock:         clockutil.Real,
 	}
+	t.Stable, t.stableSrc = splitSource(stable)
+	t.Burst, t.burstSrc = splitSource(burst)
+	return t
+}
+
+// StableSource 实际使用的稳定来源
+func (t *TripleBucket) StableSource() TokenSource {
+	return pickSource(t.stableSrc, t.Stable)
+}
+
+// BurstSource 实际使用的突发来源
+func (t *TripleBucket) BurstSource() TokenSource {
+	return pickSource(t.burstSrc, t.Burst)
+}
+
+// SetClock 替换时间来源，同时作用于支持 SetClock 的 Stable 与 Burst
+func (t *TripleBucket) SetClock(c clockutil.Clock) {
+	c = clockutil.OrReal(c)
+	for _, s := range []TokenSource{t.StableSource(), t.BurstSource()} {
+		if cs, ok := s.(clockSetter); ok {
+			cs.SetClock(c)
+		}
+	}
+	t.mu.Lock()
+	t.clock = c
+	t.mu.Unlock()
 }
 
 // TryTake 尝试拿 token：先检查是否处于 reject 状态
 func (t *TripleBucket) TryTake() bool {
-	t.mu.Lock()
-	now := time.Now()
-	if !t.rejectUntil.IsZero() && now.Before(t.rejectUntil) {
+	if t.IsRejected() {
 		// 当前处于拒绝期
-		t.mu.Unlock()
 		return false
 	}
-	t.mu.Unlock()
+	if t.take() == nil {
+		// 两桶都拿不到，算一次失败
+		t.recordFailure()
+		return false
+	}
+	t.recordSuccess()
+	return true
+}
 
-	// 正常尝试：stable -> burst
-	if t.Stable.TryTake(1.0) {
-		// success: reset failCount
-		t.mu.Lock()
-		t.failCount = 0
-		t.mu.Unlock()
-		return true
+// take 正常尝试：stable -> burst，返回拿到令牌的桶，都拿不到时返回 nil（不改动熔断状态）
+func (t *TripleBucket) take() TokenSource {
+	for _, s := range []TokenSource{t.StableSource(), t.BurstSource()} {
+		if s.TryTake(1.0) {
+			return s
+		}
 	}
-	if t.Burst.TryTake(1.0) {
-		t.mu.Lock()
-		t.failCount = 0
-		t.mu.Unlock()
-		return true
+	return nil
+}
+
+// giveBack 把 take 拿到的令牌还给对应的桶，不支持归还的 TokenSource 忽略
+func (t *TripleBucket) giveBack(src TokenSource) {
+	if r, ok := src.(refunder); ok {
+		r.giveBack(1.0)
 	}
+}
+
+// recordSuccess 成功拿到令牌：重置 failCount
+func (t *TripleBucket) recordSuccess() {
+	t.mu.Lock()
+	t.failCount = 0
+	t.mu.Unlock()
+}
 
-	// 两桶都拿不到，算一次失败
+// recordFailure 记一次失败，连续失败达到阈值时进入 reject
+func (t *TripleBucket) recordFailure() {
 	t.mu.Lock()
 	t.failCount++
 	if t.failCount >= t.failThreshold {
-		t.rejectUntil = time.Now().Add(t.rejectDur)
+		t.rejectUntil = t.clock.Now().Add(t.rejectDur)
 		// reset failCount to avoid repeated accumulation
 		t.failCount = 0
 	}
 	t.mu.Unlock()
-	return false
 }
 
 // IsRejected 当前是否处于 reject 状态
 func (t *TripleBucket) IsRejected() bool {
 	t.mu.Lock()
 	defer t.mu.Unlock()
-	return !t.rejectUntil.IsZero() && time.Now().Before(t.rejectUntil)
+	return !t.rejectUntil.IsZero() && t.clock.Now().Before(t.rejectUntil)
+}
+
+// RetryAfter 距离下一次 TryTake 可能成功还需多久（熔断剩余时间与两桶补足 1 个令牌的较短者取大）
+// 两桶都不会补足（或都无法估算）时返回 -1
+func (t *TripleBucket) RetryAfter() time.Duration {
+	t.mu.Lock()
+	reject := t.rejectUntil.Sub(t.clock.Now())
+	t.mu.Unlock()
+
+	wait := time.Duration(-1)
+	for _, d := range []time.Duration{timeToTokens(t.StableSource(), 1), timeToTokens(t.BurstSource(), 1)} {
+		if d >= 0 && (wait < 0 || d < wait) {
+			wait = d
+		}
+	}
+	if wait >= 0 && reject > wait {
+		return reject
+	}
+	return wait
+}
+
+// timeToTokens 不支持估算的 TokenSource 返回 -1
+func timeToTokens(s TokenSource, n float64) time.Duration {
+	if we, ok := s.(waitEstimator); ok {
+		return we.TimeToTokens(n)
+	}
+	return -1
 }
 
 // ResetReject 手动重置 reject 状态
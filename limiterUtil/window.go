@@ -0,0 +1,327 @@
+package limiterUtil
+
+import (
+	"sync"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+)
+
+// ---------------------------
+// 窗口限流：适合“每滚动一小时 1000 次”这类按时间窗口约定的配额
+// 三种实现都满足 TokenSource，可通过 NewDualBucketWith / NewTripleBucketWith 用作稳定桶或突发桶
+// 与 Bucket 一样支持归还：Limiter 的集群配额拒绝时，刚放行的次数会退回窗口
+// window <= 0 时按 1 秒处理，limit < 0 时按 0 处理
+// ---------------------------
+
+// FixedWindow 固定窗口计数，窗口按时间对齐（例如整点开始的每小时）
+// 实现最简单，但窗口边界两侧可能出现两倍突发
+type FixedWindow struct {
+	limit  float64
+	window time.Duration
+	count  float64
+	start  time.Time
+	clock  clockutil.Clock
+	mu     sync.Mutex
+}
+
+// defaultWindow window 非法时使用的窗口长度
+const defaultWindow = time.Second
+
+// windowArgs 修正非法参数：window <= 0 会让 Truncate 失效、加权计算出现 NaN
+func windowArgs(limit float64, window time.Duration) (float64, time.Duration) {
+	if limit < 0 {
+		limit = 0
+	}
+	if window <= 0 {
+		window = defaultWindow
+	}
+	return limit, window
+}
+
+func NewFixedWindow(limit float64, window time.Duration) *FixedWindow {
+	limit, window = windowArgs(limit, window)
+	return &FixedWindow{limit: limit, window: window, clock: clockutil.Real}
+}
+
+// SetClock 替换时间来源（线程安全）
+func (f *FixedWindow) SetClock(c clockutil.Clock) {
+	f.mu.Lock()
+	f.clock = clockutil.OrReal(c)
+	f.mu.Unlock()
+}
+
+// rollLocked 进入新窗口时清零计数
+func (f *FixedWindow) rollLocked(now time.Time) {
+	if start := now.Truncate(f.window); !start.Equal(f.start) {
+		f.start = start
+		f.count = 0
+	}
+}
+
+func (f *FixedWindow) TryTake(count float64) bool {
+	if count <= 0 {
+		return true
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rollLocked(f.clock.Now())
+	if f.count+count > f.limit {
+		return false
+	}
+	f.count += count
+	return true
+}
+
+// giveBack 归还刚放行但未使用的次数；放行时所在的窗口已经结束时无需归还
+func (f *FixedWindow) giveBack(count float64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if !f.clock.Now().Truncate(f.window).Equal(f.start) {
+		return
+	}
+	f.count = max(f.count-count, 0)
+}
+
+// Tokens 当前窗口剩余次数
+func (f *FixedWindow) Tokens() float64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.rollLocked(f.clock.Now())
+	return f.limit - f.count
+}
+
+func (f *FixedWindow) Capacity() float64 {
+	return f.limit
+}
+
+// TimeToTokens 剩余不足时需等到下一个窗口，n 超过 limit 时返回 -1
+func (f *FixedWindow) TimeToTokens(n float64) time.Duration {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	now := f.clock.Now()
+	f.rollLocked(now)
+	switch {
+	case n > f.limit:
+		return -1
+	case f.limit-f.count >= n:
+		return 0
+	}
+	return f.start.Add(f.window).Sub(now)
+}
+
+// ---------------------------
+
+type logEntry struct {
+	at time.Time
+	n  float64
+}
+
+// SlidingWindowLog 记录窗口内每次放行的时间，严格保证任意滚动窗口内不超过 limit
+// 内存占用与窗口内放行次数成正比
+type SlidingWindowLog struct {
+	limit  float64
+	window time.Duration
+	log    []logEntry
+	used   float64
+	clock  clockutil.Clock
+	mu     sync.Mutex
+}
+
+func NewSlidingWindowLog(limit float64, window time.Duration) *SlidingWindowLog {
+	limit, window = windowArgs(limit, window)
+	return &SlidingWindowLog{limit: limit, window: window, clock: clockutil.Real}
+}
+
+// SetClock 替换时间来源（线程安全）
+func (s *SlidingWindowLog) SetClock(c clockutil.Clock) {
+	s.mu.Lock()
+	s.clock = clockutil.OrReal(c)
+	s.mu.Unlock()
+}
+
+// evictLocked 删除已滑出窗口的记录
+func (s *SlidingWindowLog) evictLocked(now time.Time) {
+	cutoff := now.Add(-s.window)
+	i := 0
+	for ; i < len(s.log) && !s.log[i].at.After(cutoff); i++ {
+		s.used -= s.log[i].n
+	}
+	if i > 0 {
+		s.log = append(s.log[:0], s.log[i:]...)
+	}
+}
+
+func (s *SlidingWindowLog) TryTake(count float64) bool {
+	if count <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.evictLocked(now)
+	if s.used+count > s.limit {
+		return false
+	}
+	s.log = append(s.log, logEntry{at: now, n: count})
+	s.used += count
+	return true
+}
+
+// giveBack 从最近的记录开始撤销刚放行但未使用的次数
+func (s *SlidingWindowLog) giveBack(count float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for i := len(s.log) - 1; i >= 0 && count > 0; i-- {
+		n := min(s.log[i].n, count)
+		s.log[i].n -= n
+		s.used -= n
+		count -= n
+		if s.log[i].n <= 0 {
+			s.log = s.log[:i]
+		}
+	}
+}
+
+// Tokens 滚动窗口内剩余次数
+func (s *SlidingWindowLog) Tokens() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.evictLocked(s.clock.Now())
+	return s.limit - s.used
+}
+
+func (s *SlidingWindowLog) Capacity() float64 {
+	return s.limit
+}
+
+// TimeToTokens 按记录精确计算最早何时能腾出 n 个名额，n 超过 limit 时返回 -1
+func (s *SlidingWindowLog) TimeToTokens(n float64) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.evictLocked(now)
+	if n > s.limit {
+		return -1
+	}
+	need := s.used + n - s.limit
+	for _, e := range s.log {
+		if need <= 0 {
+			break
+		}
+		need -= e.n
+		if need <= 0 {
+			return e.at.Add(s.window).Sub(now)
+		}
+	}
+	return 0
+}
+
+// ---------------------------
+
+// SlidingWindowCounter 用前后两个固定窗口的加权和近似滚动窗口
+// 内存 O(1)，精度略低于 SlidingWindowLog（假设上一窗口内请求均匀分布）
+type SlidingWindowCounter struct {
+	limit  float64
+	window time.Duration
+	start  time.Time // 当前窗口起点
+	curr   float64
+	prev   float64
+	clock  clockutil.Clock
+	mu     sync.Mutex
+}
+
+func NewSlidingWindowCounter(limit float64, window time.Duration) *SlidingWindowCounter {
+	limit, window = windowArgs(limit, window)
+	return &SlidingWindowCounter{limit: limit, window: window, clock: clockutil.Real}
+}
+
+// SetClock 替换时间来源（线程安全）
+func (s *SlidingWindowCounter) SetClock(c clockutil.Clock) {
+	s.mu.Lock()
+	s.clock = clockutil.OrReal(c)
+	s.mu.Unlock()
+}
+
+func (s *SlidingWindowCounter) rollLocked(now time.Time) {
+	start := now.Truncate(s.window)
+	switch {
+	case start.Equal(s.start):
+	case start.Equal(s.start.Add(s.window)):
+		s.prev, s.curr = s.curr, 0
+	default:
+		s.prev, s.curr = 0, 0
+	}
+	s.start = start
+}
+
+// estimateLocked 上一窗口按仍在滚动窗口内的比例计入
+func (s *SlidingWindowCounter) estimateLocked(now time.Time) float64 {
+	elapsed := float64(now.Sub(s.start)) / float64(s.window)
+	return s.prev*(1-elapsed) + s.curr
+}
+
+func (s *SlidingWindowCounter) TryTake(count float64) bool {
+	if count <= 0 {
+		return true
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.rollLocked(now)
+	if s.estimateLocked(now)+count > s.limit {
+		return false
+	}
+	s.curr += count
+	return true
+}
+
+// giveBack 归还刚放行但未使用的次数；放行时所在的窗口已经结束时无需归还
+func (s *SlidingWindowCounter) giveBack(count float64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if !s.clock.Now().Truncate(s.window).Equal(s.start) {
+		return
+	}
+	s.curr = max(s.curr-count, 0)
+}
+
+// Tokens 估算的剩余次数
+func (s *SlidingWindowCounter) Tokens() float64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.rollLocked(now)
+	return s.limit - s.estimateLocked(now)
+}
+
+func (s *SlidingWindowCounter) Capacity() float64 {
+	return s.limit
+}
+
+// TimeToTokens 按加权公式反解等待时间，n 超过 limit 时返回 -1
+func (s *SlidingWindowCounter) TimeToTokens(n float64) time.Duration {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	now := s.clock.Now()
+	s.rollLocked(now)
+	if n > s.limit {
+		return -1
+	}
+	if s.estimateLocked(now)+n <= s.limit {
+		return 0
+	}
+	w := float64(s.window)
+	elapsed := float64(now.Sub(s.start))
+	if room := s.limit - n - s.curr; room >= 0 && s.prev > 0 {
+		// 当前窗口内上一窗口的权重衰减到 room 即可
+		return time.Duration(w*(1-room/s.prev) - elapsed)
+	}
+	// 需要等到下一个窗口，届时当前计数成为上一窗口
+	toNext := w - elapsed
+	t := w * (1 - (s.limit-n)/s.curr)
+	if t < 0 {
+		t = 0
+	}
+	return time.Duration(toNext + t)
+}
@@ -0,0 +1,124 @@
+package unitTestForUtils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+func TestFakeClockTokenExpiry(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	m := newTestManager()
+	m.SetClock(clock)
+
+	token, _ := m.GenerateToken(jwt.MapClaims{"user_id": "u1"})
+	clock.Advance(59 * time.Second)
+	if _, err := m.ParseToken(token); err != nil {
+		t.Fatalf("token should still be valid: %v", err)
+	}
+	clock.Advance(2 * time.Second)
+	if _, err := m.ParseToken(token); !errors.Is(err, jwtutil.ErrTokenExpired) {
+		t.Fatalf("expected ErrTokenExpired, got %v", err)
+	}
+}
+
+func TestFakeClockBucketRefill(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	b := limiterUtil.NewBucket(2, 1)
+	b.SetClock(clock)
+
+	if !b.TryTake(2) || b.TakeOne() {
+		t.Fatalf("expected bucket to drain after taking capacity")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if b.TakeOne() {
+		t.Fatalf("half a token should not be enough")
+	}
+	clock.Advance(500 * time.Millisecond)
+	if !b.TakeOne() {
+		t.Fatalf("expected one token after 1s")
+	}
+	clock.Advance(time.Hour)
+	if got := b.Tokens(); got != 2 {
+		t.Fatalf("tokens should be capped at capacity, got %v", got)
+	}
+}
+
+func TestFakeClockTripleBucketReject(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	tb := limiterUtil.NewTripleBucket(1, 0, 0, 0, 2, 10*time.Second)
+	tb.SetClock(clock)
+
+	tb.TryTake()
+	tb.TryTake()
+	tb.TryTake()
+	if !tb.IsRejected() {
+		t.Fatalf("expected reject after consecutive failures")
+	}
+	clock.Advance(10*time.Second + time.Millisecond)
+	if tb.IsRejected() {
+		t.Fatalf("reject should end after cooldown")
+	}
+}
+
+func TestFakeClockQueueExpiry(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	q := limiterUtil.NewQueue[int]()
+	q.SetCleanupInterval(time.Hour)
+	q.SetClock(clock)
+	defer q.Stop()
+
+	q.Enqueue(1, time.Second)
+	q.Enqueue(2, time.Minute)
+	clock.Advance(2 * time.Second)
+	if v, ok := q.Dequeue(context.Background()); !ok || v != 2 {
+		t.Fatalf("expected expired item to be skipped, got %v %v", v, ok)
+	}
+}
+
+func TestFakeClockLimiter(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	limiter := limiterUtil.NewLimiter[int](limiterUtil.LimiterConfig{
+		StableCap:      1,
+		StableRate:     1,
+		FailThreshold:  100,
+		QueueCleanup:   time.Hour,
+		WorkerInterval: time.Millisecond,
+		QueueItemTTL:   time.Minute,
+		Clock:          clock,
+	})
+	processed := make(chan int, 2)
+	limiter.SetOnProcess(func(i int) { processed <- i })
+	limiter.Start()
+	defer limiter.Stop()
+
+	if s := limiter.Submit(1); s != limiterUtil.StateTaken {
+		t.Fatalf("expected StateTaken, got %v", s)
+	}
+	if s := limiter.Submit(2); s != limiterUtil.StateQueued {
+		t.Fatalf("expected StateQueued, got %v", s)
+	}
+	<-processed
+
+	// 时间不推进，队列中的请求拿不到令牌
+	select {
+	case i := <-processed:
+		t.Fatalf("item %d processed before refill", i)
+	case <-time.After(50 * time.Millisecond):
+	}
+	clock.Advance(time.Second)
+	select {
+	case i := <-processed:
+		if i != 2 {
+			t.Fatalf("expected item 2, got %d", i)
+		}
+	case <-time.After(time.Second):
+		t.Fatalf("queued item not processed after refill")
+	}
+}
@@ -0,0 +1,149 @@
+package unitTestForUtils
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+// 签发方发布 JWKS，校验方通过 URL 校验 token
+func TestJWKSPublishAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	ring := jwtutil.NewKeyRing()
+	ring.AddKey("rsa", jwt.SigningMethodPS256, rsaKey)
+	ring.AddKey("ec", jwt.SigningMethodES384, ecKey)
+	ring.AddKey("ed", jwt.SigningMethodEdDSA, edKey)
+	ring.AddKey("hmac", jwt.SigningMethodHS256, []byte("private"))
+
+	var hits int32
+	handler := jwtutil.JWKSHandler(ring, time.Minute)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	if n := len(ring.JWKS().Keys); n != 3 {
+		t.Fatalf("expected 3 public keys (hmac hidden), got %d", n)
+	}
+
+	signer := jwtutil.NewJWTManagerWithKeyRing(ring, time.Minute, "jwt_token")
+	verifier, _ := jwtutil.NewJWKSVerifier(srv.URL, "jwt_token")
+
+	for _, kid := range []string{"rsa", "ec", "ed"} {
+		ring.SetActive(kid)
+		token, err := signer.GenerateToken(jwt.MapClaims{"sub": kid})
+		if err != nil {
+			t.Fatalf("GenerateToken(%s) failed: %v", kid, err)
+		}
+		if _, err := verifier.ParseToken(token); err != nil {
+			t.Fatalf("ParseToken(%s) failed: %v", kid, err)
+		}
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Errorf("expected JWKS to be fetched once and cached, got %d fetches", got)
+	}
+	if _, err := verifier.GenerateToken(jwt.MapClaims{}); err != jwtutil.ErrNoSigningKey {
+		t.Errorf("JWKS verifier should not sign, got %v", err)
+	}
+}
+
+// 出现未知 kid 时重新拉取 JWKS
+func TestJWKSRefetchOnUnknownKid(t *testing.T) {
+	ring := jwtutil.NewKeyRing()
+	k1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring.AddKey("k1", jwt.SigningMethodES256, k1)
+	ring.SetActive("k1")
+
+	srv := httptest.NewServer(jwtutil.JWKSHandler(ring, 0))
+	defer srv.Close()
+
+	set := jwtutil.NewJWKSKeySet(srv.URL)
+	set.MinRefetchInterval = 0
+	if err := set.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	verifier := jwtutil.NewJWTVerifierWithKeySet(set, "jwt_token")
+	signer := jwtutil.NewJWTManagerWithKeyRing(ring, time.Minute, "jwt_token")
+
+	k2, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring.AddKey("k2", jwt.SigningMethodES256, k2)
+	ring.SetActive("k2")
+
+	token, _ := signer.GenerateToken(jwt.MapClaims{})
+	if _, err := verifier.ParseToken(token); err != nil {
+		t.Fatalf("expected refetch to pick up k2: %v", err)
+	}
+
+	forged, _ := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{}).SignedString(k2)
+	if _, err := verifier.ParseToken(forged); err == nil {
+		t.Fatalf("token without kid should be rejected")
+	}
+}
+
+// 拉取失败后同样遵守最小间隔，过期缓存中的公钥立即返回
+func TestJWKSFailedFetchBacksOff(t *testing.T) {
+	ring := jwtutil.NewKeyRing()
+	k1, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring.AddKey("k1", jwt.SigningMethodES256, k1)
+
+	var hits, failing int32
+	handler := jwtutil.JWKSHandler(ring, 0)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		if atomic.LoadInt32(&failing) == 1 {
+			http.Error(w, "unavailable", http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	set := jwtutil.NewJWKSKeySet(srv.URL)
+	set.RefreshInterval = time.Millisecond
+	set.MinRefetchInterval = time.Minute
+	if err := set.Refresh(context.Background()); err != nil {
+		t.Fatalf("Refresh failed: %v", err)
+	}
+	atomic.StoreInt32(&failing, 1)
+	time.Sleep(5 * time.Millisecond)
+
+	for i := 0; i < 50; i++ {
+		if k, err := set.Lookup("k1"); err != nil || k.ID != "k1" {
+			t.Fatalf("stale key should be returned, got %v %v", k, err)
+		}
+		if _, err := set.Lookup("unknown"); err == nil {
+			t.Fatal("unknown kid should not be found")
+		}
+	}
+	if got := atomic.LoadInt32(&hits); got != 1 {
+		t.Fatalf("expected no refetch within MinRefetchInterval, got %d fetches", got)
+	}
+
+	// 最小间隔过后允许再试一次，失败后继续退避
+	set.MinRefetchInterval = 50 * time.Millisecond
+	set.RefreshInterval = time.Minute
+	time.Sleep(60 * time.Millisecond)
+	set.Lookup("unknown")
+	set.Lookup("unknown")
+	if got := atomic.LoadInt32(&hits); got != 2 {
+		t.Fatalf("expected exactly one retry, got %d fetches", got)
+	}
+	if _, err := set.Lookup("k1"); err != nil {
+		t.Fatalf("cached key should survive failed fetches: %v", err)
+	}
+}
@@ -0,0 +1,124 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func newTestCSRF(t *testing.T, onError func(http.ResponseWriter, *http.Request, error)) (*jwtutil.JWTManager, *jwtutil.CSRF, string, string) {
+	m := newTestManager()
+	c, err := jwtutil.NewCSRF(m, jwtutil.CSRFConfig{
+		Key:            []byte("csrf-key"),
+		TrustedOrigins: []string{"https://app.example.com"},
+		OnError:        onError,
+	})
+	if err != nil {
+		t.Fatalf("NewCSRF failed: %v", err)
+	}
+	session, _ := m.GenerateToken(jwt.MapClaims{"user_id": 1})
+	w := httptest.NewRecorder()
+	csrfToken, err := c.Issue(w, session)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	if w.Header().Get("X-CSRF-Token") != csrfToken {
+		t.Fatalf("csrf token should be exposed in response header")
+	}
+	return m, c, session, csrfToken
+}
+
+func csrfRequest(m *jwtutil.JWTManager, session, cookieToken, headerToken, origin string) *http.Request {
+	r := httptest.NewRequest(http.MethodPost, "/transfer", nil)
+	r.AddCookie(&http.Cookie{Name: m.CookieName, Value: session})
+	if cookieToken != "" {
+		r.AddCookie(&http.Cookie{Name: "csrf_token", Value: cookieToken})
+	}
+	if headerToken != "" {
+		r.Header.Set("X-CSRF-Token", headerToken)
+	}
+	if origin != "" {
+		r.Header.Set("Origin", origin)
+	}
+	return r
+}
+
+func TestCSRFMiddleware(t *testing.T) {
+	var gotErr error
+	m, c, session, token := newTestCSRF(t, func(w http.ResponseWriter, r *http.Request, err error) {
+		gotErr = err
+		w.WriteHeader(http.StatusForbidden)
+	})
+	h := c.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	other, _ := m.GenerateToken(jwt.MapClaims{"user_id": 2})
+	otherToken := c.Token("not-the-session-jti")
+
+	cases := []struct {
+		name string
+		r    *http.Request
+		want error
+	}{
+		{"valid", csrfRequest(m, session, token, token, "https://app.example.com"), nil},
+		{"missing header", csrfRequest(m, session, token, "", "https://app.example.com"), jwtutil.ErrCSRFTokenMissing},
+		{"mismatch", csrfRequest(m, session, token, otherToken, "https://app.example.com"), jwtutil.ErrCSRFTokenInvalid},
+		{"bound to other session", csrfRequest(m, other, token, token, "https://app.example.com"), jwtutil.ErrCSRFTokenInvalid},
+		{"bad origin", csrfRequest(m, session, token, token, "https://evil.example.com"), jwtutil.ErrOriginNotAllowed},
+	}
+	for _, tc := range cases {
+		gotErr = nil
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, tc.r)
+		if !errors.Is(gotErr, tc.want) || (tc.want == nil && w.Code != http.StatusOK) {
+			t.Errorf("%s: expected %v, got %v (status %d)", tc.name, tc.want, gotErr, w.Code)
+		}
+	}
+
+	// 安全方法与无会话 Cookie 的请求不检查
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusOK {
+		t.Errorf("GET should pass, got %d", w.Code)
+	}
+	r := httptest.NewRequest(http.MethodPost, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+session)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Errorf("bearer request should pass, got %d", w.Code)
+	}
+}
+
+// 会话续签后 CSRF token 与 Cookie 依然有效
+func TestCSRFAfterRenewal(t *testing.T) {
+	m, c, _, _ := newTestCSRF(t, nil)
+	old := agedToken(m, 40*time.Second, 20*time.Second, nil)
+	w := httptest.NewRecorder()
+	token, err := c.Issue(w, old)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+	for _, ck := range w.Result().Cookies() {
+		if ck.Name == "csrf_token" && (ck.MaxAge != 0 || !ck.Expires.IsZero()) {
+			t.Fatalf("csrf cookie should be a session cookie, got MaxAge=%d Expires=%v", ck.MaxAge, ck.Expires)
+		}
+	}
+
+	auth := m.Middleware(jwtutil.MiddlewareConfig{Renewal: &jwtutil.RenewalPolicy{Threshold: 0.5}})
+	h := auth(c.Middleware()(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})))
+	cookies := serveWithCookie(h, m, old).Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected renewed session cookie")
+	}
+
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, csrfRequest(m, cookies[0].Value, token, token, "https://app.example.com"))
+	if w.Code != http.StatusOK {
+		t.Fatalf("POST after renewal should pass CSRF, got %d: %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,94 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func newValidatingManager() *jwtutil.JWTManager {
+	m := newTestManager()
+	m.Issuer = "auth.example.com"
+	m.Audience = []string{"api", "admin"}
+	m.SubjectClaim = "user_id"
+	return m
+}
+
+// GenerateToken 自动写入注册 claim
+func TestGenerateFillsRegisteredClaims(t *testing.T) {
+	m := newValidatingManager()
+	token, _ := m.GenerateToken(jwt.MapClaims{"user_id": 42})
+	claims, err := m.ParseToken(token)
+	if err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+	for _, name := range []string{"iss", "aud", "iat", "nbf", "jti", "exp"} {
+		if _, ok := claims[name]; !ok {
+			t.Errorf("claim %s should be filled", name)
+		}
+	}
+	if claims["sub"] != "42" {
+		t.Errorf("sub should be copied from user_id, got %v", claims["sub"])
+	}
+}
+
+// 各类违规返回不同的错误
+func TestRegisteredClaimViolations(t *testing.T) {
+	m := newValidatingManager()
+	now := time.Now()
+
+	sign := func(claims jwt.MapClaims) string {
+		s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.Secret)
+		return s
+	}
+	base := func() jwt.MapClaims {
+		return jwt.MapClaims{"iss": m.Issuer, "aud": "api", "exp": now.Add(time.Minute).Unix()}
+	}
+
+	cases := []struct {
+		name   string
+		mutate func(jwt.MapClaims)
+		want   error
+	}{
+		{"wrong issuer", func(c jwt.MapClaims) { c["iss"] = "evil" }, jwtutil.ErrInvalidIssuer},
+		{"wrong audience", func(c jwt.MapClaims) { c["aud"] = "other" }, jwtutil.ErrInvalidAudience},
+		{"expired", func(c jwt.MapClaims) { c["exp"] = now.Add(-time.Minute).Unix() }, jwtutil.ErrTokenExpired},
+		{"not yet valid", func(c jwt.MapClaims) { c["nbf"] = now.Add(time.Minute).Unix() }, jwtutil.ErrTokenNotValidYet},
+		{"issued in future", func(c jwt.MapClaims) { c["iat"] = now.Add(time.Minute).Unix() }, jwtutil.ErrTokenUsedBeforeIssued},
+		{"missing issuer", func(c jwt.MapClaims) { delete(c, "iss") }, jwtutil.ErrMissingClaim},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			claims := base()
+			c.mutate(claims)
+			if _, err := m.ParseToken(sign(claims)); !errors.Is(err, c.want) {
+				t.Errorf("expected %v, got %v", c.want, err)
+			}
+		})
+	}
+
+	// 容忍时钟偏差
+	m.Leeway = 2 * time.Minute
+	claims := base()
+	claims["exp"] = now.Add(-time.Minute).Unix()
+	if _, err := m.ParseToken(sign(claims)); err != nil {
+		t.Errorf("leeway should accept slightly expired token: %v", err)
+	}
+}
+
+func TestRequiredClaims(t *testing.T) {
+	m := newTestManager()
+	m.RequiredClaims = []string{"role"}
+
+	token, _ := m.GenerateToken(jwt.MapClaims{"user_id": 1})
+	if _, err := m.ParseToken(token); !errors.Is(err, jwtutil.ErrMissingClaim) {
+		t.Fatalf("expected ErrMissingClaim, got %v", err)
+	}
+	token, _ = m.GenerateToken(jwt.MapClaims{"user_id": 1, "role": "admin"})
+	if _, err := m.ParseToken(token); err != nil {
+		t.Fatalf("ParseToken failed: %v", err)
+	}
+}
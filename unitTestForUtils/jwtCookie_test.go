@@ -0,0 +1,66 @@
+package unitTestForUtils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func TestCookieOptionsApplied(t *testing.T) {
+	m := newTestManager()
+	err := m.SetCookieOptions(jwtutil.CookieOptions{
+		Secure:      true,
+		SameSite:    http.SameSiteNoneMode,
+		Partitioned: true,
+		Prefix:      jwtutil.CookiePrefixHost,
+	})
+	if err != nil {
+		t.Fatalf("SetCookieOptions failed: %v", err)
+	}
+
+	w := httptest.NewRecorder()
+	m.SetTokenCookie(w, "mock-token")
+	c := w.Result().Cookies()[0]
+	if c.Name != "__Host-jwt_token" || !c.Secure || c.Path != "/" || c.SameSite != http.SameSiteNoneMode || !c.Partitioned {
+		t.Errorf("unexpected cookie attributes: %+v", c)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: "__Host-jwt_token", Value: "mock-token"})
+	if token, err := m.ReadTokenFromCookie(r); err != nil || token != "mock-token" {
+		t.Errorf("ReadTokenFromCookie should use prefixed name, got %q %v", token, err)
+	}
+}
+
+func TestCookieOptionsInvalid(t *testing.T) {
+	invalid := map[string]jwtutil.CookieOptions{
+		"none without secure":        {SameSite: http.SameSiteNoneMode},
+		"partitioned without secure": {Partitioned: true},
+		"secure prefix":              {Prefix: jwtutil.CookiePrefixSecure},
+		"host prefix with domain":    {Secure: true, Prefix: jwtutil.CookiePrefixHost, Domain: "example.com"},
+		"host prefix with path":      {Secure: true, Prefix: jwtutil.CookiePrefixHost, Path: "/api"},
+	}
+	for name, opts := range invalid {
+		m := newTestManager()
+		if err := m.SetCookieOptions(opts); err == nil {
+			t.Errorf("%s: expected error", name)
+		}
+		if m.CookieOptions() != jwtutil.DefaultCookieOptions() {
+			t.Errorf("%s: options should be unchanged on error", name)
+		}
+	}
+}
+
+func TestClearTokenCookie(t *testing.T) {
+	m := newTestManager()
+	m.SetCookieOptions(jwtutil.CookieOptions{Secure: true, Domain: "example.com"})
+
+	w := httptest.NewRecorder()
+	m.ClearTokenCookie(w)
+	c := w.Result().Cookies()[0]
+	if c.Name != m.CookieName || c.Value != "" || c.MaxAge >= 0 || c.Domain != "example.com" {
+		t.Errorf("unexpected clearing cookie: %+v", c)
+	}
+}
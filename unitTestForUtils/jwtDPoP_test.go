@@ -0,0 +1,132 @@
+package unitTestForUtils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func newTestProver(t *testing.T) *jwtutil.DPoPProver {
+	key, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p, err := jwtutil.NewDPoPProver(jwt.SigningMethodES256, key)
+	if err != nil {
+		t.Fatalf("NewDPoPProver failed: %v", err)
+	}
+	return p
+}
+
+func TestDPoPMiddleware(t *testing.T) {
+	m := newTestManager()
+	prover := newTestProver(t)
+	claims := jwt.MapClaims{"role": "admin"}
+	jwtutil.BindDPoPKey(claims, prover.Thumbprint())
+	token, _ := m.GenerateToken(claims)
+
+	h := m.Middleware(jwtutil.MiddlewareConfig{DPoP: jwtutil.NewDPoPVerifier()})(claimsEchoHandler(t))
+	serve := func(r *http.Request) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "http://api.example.com/orders?page=2", nil)
+	if err := prover.Apply(r, token); err != nil {
+		t.Fatalf("Apply failed: %v", err)
+	}
+	if code := serve(r); code != http.StatusOK {
+		t.Fatalf("valid proof: expected 200, got %d", code)
+	}
+
+	// 同一个 proof 重放
+	replay := httptest.NewRequest(http.MethodGet, "http://api.example.com/orders", nil)
+	replay.Header = r.Header.Clone()
+	if code := serve(replay); code != http.StatusUnauthorized {
+		t.Errorf("replayed proof: expected 401, got %d", code)
+	}
+
+	// 被盗 token 不带 proof
+	r = httptest.NewRequest(http.MethodGet, "http://api.example.com/orders", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	if code := serve(r); code != http.StatusUnauthorized {
+		t.Errorf("missing proof: expected 401, got %d", code)
+	}
+
+	// 附带了有效 proof，但 token 通过 Bearer 或 Cookie 传递
+	for name, attach := range map[string]func(*http.Request){
+		"bearer": func(r *http.Request) { r.Header.Set("Authorization", "Bearer "+token) },
+		"cookie": func(r *http.Request) {
+			r.Header.Del("Authorization")
+			r.AddCookie(&http.Cookie{Name: m.CookieName, Value: token})
+		},
+	} {
+		r = httptest.NewRequest(http.MethodGet, "http://api.example.com/orders", nil)
+		prover.Apply(r, token)
+		attach(r)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusUnauthorized || !strings.HasPrefix(w.Header().Get("WWW-Authenticate"), "DPoP") {
+			t.Errorf("%s with proof: expected 401 DPoP challenge, got %d %q", name, w.Code, w.Header().Get("WWW-Authenticate"))
+		}
+	}
+
+	// proof 签发给其他方法/URL
+	r = httptest.NewRequest(http.MethodDelete, "http://api.example.com/orders", nil)
+	proof, _ := prover.Proof(http.MethodGet, "http://api.example.com/orders", token)
+	r.Header.Set("Authorization", "DPoP "+token)
+	r.Header.Set(jwtutil.DPoPHeader, proof)
+	if code := serve(r); code != http.StatusUnauthorized {
+		t.Errorf("htm mismatch: expected 401, got %d", code)
+	}
+
+	// 其他密钥生成的 proof
+	r = httptest.NewRequest(http.MethodGet, "http://api.example.com/orders", nil)
+	newTestProver(t).Apply(r, token)
+	if code := serve(r); code != http.StatusUnauthorized {
+		t.Errorf("foreign key: expected 401, got %d", code)
+	}
+}
+
+func TestDPoPVerifyErrors(t *testing.T) {
+	prover := newTestProver(t)
+	v := jwtutil.NewDPoPVerifier()
+
+	r := httptest.NewRequest(http.MethodPost, "http://auth.example.com/token", nil)
+	if _, err := v.Verify(r, ""); !errors.Is(err, jwtutil.ErrDPoPProofMissing) {
+		t.Errorf("expected ErrDPoPProofMissing, got %v", err)
+	}
+
+	// 与访问 token 不匹配的 ath
+	prover.Apply(r, "token-a")
+	if _, err := v.Verify(r, "token-b"); !errors.Is(err, jwtutil.ErrInvalidDPoPProof) {
+		t.Errorf("expected ErrInvalidDPoPProof, got %v", err)
+	}
+
+	// token 端点上的 proof 不带 access token，返回公钥指纹用于绑定
+	r = httptest.NewRequest(http.MethodPost, "http://auth.example.com/token", nil)
+	prover.Apply(r, "")
+	jkt, err := v.Verify(r, "")
+	if err != nil || jkt != prover.Thumbprint() {
+		t.Errorf("expected thumbprint %s, got %s (%v)", prover.Thumbprint(), jkt, err)
+	}
+
+	// 未配置 DPoP 的中间件拒绝已绑定的 token
+	m := newTestManager()
+	claims := jwt.MapClaims{"role": "admin"}
+	jwtutil.BindDPoPKey(claims, prover.Thumbprint())
+	token, _ := m.GenerateToken(claims)
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	m.Middleware(jwtutil.MiddlewareConfig{})(claimsEchoHandler(t)).ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("bound token without verifier: expected 401, got %d", w.Code)
+	}
+}
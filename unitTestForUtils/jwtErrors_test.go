@@ -0,0 +1,150 @@
+package unitTestForUtils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func TestParseTokenErrorKinds(t *testing.T) {
+	m := newTestManager()
+	m.Audience = []string{"api"}
+	m.SetRevocationStore(jwtutil.NewMemoryRevocationStore(time.Minute))
+
+	valid, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+	revoked, _ := m.GenerateToken(jwt.MapClaims{"sub": "u2"})
+	m.RevokeToken(revoked)
+
+	sign := func(method jwt.SigningMethod, key interface{}, claims jwt.MapClaims) string {
+		s, err := jwt.NewWithClaims(method, claims).SignedString(key)
+		if err != nil {
+			t.Fatalf("sign failed: %v", err)
+		}
+		return s
+	}
+	now := time.Now()
+	base := func(extra jwt.MapClaims) jwt.MapClaims {
+		c := jwt.MapClaims{"aud": "api", "iat": now.Unix(), "exp": now.Add(time.Minute).Unix()}
+		for k, v := range extra {
+			c[k] = v
+		}
+		return c
+	}
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+
+	cases := map[string]struct {
+		token string
+		kind  error
+	}{
+		"expired":        {sign(jwt.SigningMethodHS256, m.Secret, base(jwt.MapClaims{"exp": now.Add(-time.Minute).Unix(), "iat": now.Add(-2 * time.Minute).Unix()})), jwtutil.ErrTokenExpired},
+		"not yet valid":  {sign(jwt.SigningMethodHS256, m.Secret, base(jwt.MapClaims{"nbf": now.Add(time.Minute).Unix()})), jwtutil.ErrTokenNotValidYet},
+		"bad signature":  {valid[:len(valid)-4] + "AAAA", jwtutil.ErrInvalidSignature},
+		"malformed":      {"not-a-token", jwtutil.ErrTokenMalformed},
+		"wrong alg":      {sign(jwt.SigningMethodES256, ecKey, base(nil)), jwtutil.ErrUnexpectedAlgorithm},
+		"alg none":       {sign(jwt.SigningMethodNone, jwt.UnsafeAllowNoneSignatureType, base(nil)), jwtutil.ErrUnexpectedAlgorithm},
+		"revoked":        {revoked, jwtutil.ErrTokenRevoked},
+		"audience":       {sign(jwt.SigningMethodHS256, m.Secret, base(jwt.MapClaims{"aud": "other"})), jwtutil.ErrInvalidAudience},
+		"unknown header": {"eyJhbGciOiJYWVoifQ.e30.", jwtutil.ErrUnexpectedAlgorithm},
+	}
+	for name, c := range cases {
+		_, err := m.ParseToken(c.token)
+		if !errors.Is(err, c.kind) {
+			t.Errorf("%s: expected %v, got %v", name, c.kind, err)
+			continue
+		}
+		if !errors.Is(err, jwtutil.ErrInvalidToken) {
+			t.Errorf("%s: expected error to match ErrInvalidToken", name)
+		}
+		var te *jwtutil.TokenError
+		if !errors.As(err, &te) || te.Kind != c.kind {
+			t.Errorf("%s: expected *TokenError with kind %v, got %#v", name, c.kind, err)
+		}
+	}
+
+	if _, err := m.ParseToken(valid); err != nil {
+		t.Fatalf("valid token rejected: %v", err)
+	}
+}
+
+func TestMiddlewareWWWAuthenticate(t *testing.T) {
+	m := newTestManager()
+	h := m.Middleware(jwtutil.MiddlewareConfig{})(claimsEchoHandler(t))
+
+	// 没有凭据：不带 error 码
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if got := w.Header().Get("WWW-Authenticate"); got != "Bearer" {
+		t.Errorf("no token: unexpected challenge %q", got)
+	}
+
+	expired, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, jwt.MapClaims{
+		"exp": time.Now().Add(-time.Minute).Unix(),
+	}).SignedString(m.Secret)
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+expired)
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	got := w.Header().Get("WWW-Authenticate")
+	if w.Code != http.StatusUnauthorized || !strings.HasPrefix(got, `Bearer error="invalid_token"`) || !strings.Contains(got, "token expired") {
+		t.Errorf("expired: unexpected response %d %q", w.Code, got)
+	}
+
+	// scope 不足：403 + insufficient_scope
+	token, _ := m.GenerateToken(jwt.MapClaims{"role": "admin", "scope": "orders:read"})
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w = httptest.NewRecorder()
+	m.Middleware(jwtutil.MiddlewareConfig{})(jwtutil.RequireAnyScope("orders:write")(claimsEchoHandler(t))).ServeHTTP(w, r)
+	got = w.Header().Get("WWW-Authenticate")
+	if w.Code != http.StatusForbidden || got != `Bearer error="insufficient_scope", scope="orders:write"` {
+		t.Errorf("scope: unexpected response %d %q", w.Code, got)
+	}
+}
+
+// 撤销列表故障的 store
+type brokenRevocationStore struct{ *jwtutil.MemoryRevocationStore }
+
+func (brokenRevocationStore) IsRevoked(string, string, time.Time) (bool, error) {
+	return false, errors.New("connection refused")
+}
+
+// 撤销列表故障属于后端错误：仍是 *TokenError，但中间件返回 503 而不是 401
+func TestRevocationStoreFailure(t *testing.T) {
+	store := brokenRevocationStore{jwtutil.NewMemoryRevocationStore(time.Minute)}
+	defer store.Stop()
+	m := newTestManager()
+	token, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+	m.SetRevocationStore(store)
+
+	_, err := m.ParseToken(token)
+	var te *jwtutil.TokenError
+	if !errors.As(err, &te) || te.Kind != jwtutil.ErrRevocationUnavailable || !errors.Is(err, jwtutil.ErrInvalidToken) {
+		t.Fatalf("expected TokenError with ErrRevocationUnavailable, got %#v", err)
+	}
+	if errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatal("store failure must not look like a revoked token")
+	}
+	if code := jwtutil.AuthErrorCode(err); code != "temporarily_unavailable" {
+		t.Errorf("unexpected error code %q", code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	m.Middleware(jwtutil.MiddlewareConfig{})(claimsEchoHandler(t)).ServeHTTP(w, r)
+	if w.Code != http.StatusServiceUnavailable || w.Header().Get("WWW-Authenticate") != "" {
+		t.Errorf("expected 503 without challenge, got %d %q", w.Code, w.Header().Get("WWW-Authenticate"))
+	}
+	if strings.Contains(w.Body.String(), "connection refused") {
+		t.Errorf("backend details should not leak: %s", w.Body.String())
+	}
+}
@@ -0,0 +1,57 @@
+package unitTestForUtils
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func serveWithClaims(h http.Handler, claims jwt.MapClaims) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	if claims != nil {
+		r = r.WithContext(jwtutil.ContextWithClaims(r.Context(), claims))
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestRequireRoleAndScope(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+
+	admin := jwtutil.RequireRole("admin")(ok)
+	if w := serveWithClaims(admin, jwt.MapClaims{"role": "admin"}); w.Code != http.StatusOK {
+		t.Errorf("role claim: expected 200, got %d", w.Code)
+	}
+	if w := serveWithClaims(admin, jwt.MapClaims{"roles": []interface{}{"user", "admin"}}); w.Code != http.StatusOK {
+		t.Errorf("roles claim: expected 200, got %d", w.Code)
+	}
+	w := serveWithClaims(admin, jwt.MapClaims{"role": "user"})
+	if w.Code != http.StatusForbidden {
+		t.Fatalf("expected 403, got %d", w.Code)
+	}
+	var body map[string]string
+	if err := json.Unmarshal(w.Body.Bytes(), &body); err != nil || body["error"] != "forbidden" {
+		t.Errorf("expected structured JSON error, got %s", w.Body.String())
+	}
+	if w := serveWithClaims(admin, nil); w.Code != http.StatusUnauthorized {
+		t.Errorf("no claims: expected 401, got %d", w.Code)
+	}
+
+	write := jwtutil.RequireAnyScope("orders:write", "orders:admin")(ok)
+	if w := serveWithClaims(write, jwt.MapClaims{"scope": "orders:read orders:write"}); w.Code != http.StatusOK {
+		t.Errorf("scope claim: expected 200, got %d", w.Code)
+	}
+	if w := serveWithClaims(write, jwt.MapClaims{"scope": "orders:read"}); w.Code != http.StatusForbidden {
+		t.Errorf("missing scope: expected 403, got %d", w.Code)
+	}
+
+	owner := jwtutil.Require(func(c jwt.MapClaims) bool { return c["tenant"] == "acme" })(ok)
+	if w := serveWithClaims(owner, jwt.MapClaims{"tenant": "other"}); w.Code != http.StatusForbidden {
+		t.Errorf("predicate: expected 403, got %d", w.Code)
+	}
+}
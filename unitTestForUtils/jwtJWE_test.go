@@ -0,0 +1,92 @@
+package unitTestForUtils
+
+import (
+	"crypto/ecdh"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+// 各密钥管理算法：加密后的 token 为 5 段，claims 不可直接读取，解析时透明解密
+func TestJWERoundTrip(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	xKey, _ := ecdh.X25519().GenerateKey(rand.Reader)
+	secret := make([]byte, 32)
+	rand.Read(secret)
+
+	cases := []struct {
+		alg string
+		key interface{}
+	}{
+		{jwtutil.JWEDirect, secret},
+		{jwtutil.JWERSAOAEP, rsaKey},
+		{jwtutil.JWERSAOAEP256, rsaKey},
+		{jwtutil.JWEECDHES, ecKey},
+		{jwtutil.JWEECDHES, xKey},
+	}
+	for _, c := range cases {
+		m := newTestManager()
+		if err := m.SetEncryption(c.alg, c.key); err != nil {
+			t.Fatalf("%s: SetEncryption failed: %v", c.alg, err)
+		}
+		token, err := m.GenerateToken(jwt.MapClaims{"email": "abc@test.com"})
+		if err != nil {
+			t.Fatalf("%s: GenerateToken failed: %v", c.alg, err)
+		}
+		if n := len(strings.Split(token, ".")); n != 5 {
+			t.Fatalf("%s: expected compact JWE with 5 parts, got %d", c.alg, n)
+		}
+		claims, err := m.ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s: ParseToken failed: %v", c.alg, err)
+		}
+		if claims["email"] != "abc@test.com" {
+			t.Errorf("%s: email mismatch: %v", c.alg, claims["email"])
+		}
+	}
+}
+
+func TestJWERejectsTampering(t *testing.T) {
+	secret := make([]byte, 32)
+	rand.Read(secret)
+	m := newTestManager()
+	m.SetEncryption(jwtutil.JWEDirect, secret)
+	token, _ := m.GenerateToken(jwt.MapClaims{"email": "abc@test.com"})
+
+	parts := strings.Split(token, ".")
+	ct, _ := base64.RawURLEncoding.DecodeString(parts[3])
+	ct[0] ^= 0xff
+	parts[3] = base64.RawURLEncoding.EncodeToString(ct)
+	if _, err := m.ParseToken(strings.Join(parts, ".")); !errors.Is(err, jwtutil.ErrTokenDecryption) {
+		t.Errorf("expected ErrTokenDecryption, got %v", err)
+	}
+
+	// 开启加密后不接受未加密的 token
+	plain, _ := newTestManager().GenerateToken(jwt.MapClaims{})
+	if _, err := m.ParseToken(plain); err == nil {
+		t.Errorf("plain JWS should be rejected in JWE mode")
+	}
+
+	// 只持有公钥的一方只能加密
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	enc := newTestManager()
+	enc.SetEncryption(jwtutil.JWERSAOAEP256, &rsaKey.PublicKey)
+	token, _ = enc.GenerateToken(jwt.MapClaims{})
+	if _, err := enc.ParseToken(token); err == nil {
+		t.Errorf("public-key-only manager should not decrypt")
+	}
+	dec := newTestManager()
+	dec.SetEncryption(jwtutil.JWERSAOAEP256, rsaKey)
+	if _, err := dec.ParseToken(token); err != nil {
+		t.Errorf("private key holder should decrypt: %v", err)
+	}
+}
@@ -0,0 +1,72 @@
+package unitTestForUtils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+// 轮换流程：旧 key 签发的 token 在新 key 激活后仍可校验，退役后失效
+func TestKeyRingRotation(t *testing.T) {
+	ring := jwtutil.NewKeyRing()
+	if err := ring.AddKey("k1", jwt.SigningMethodHS256, []byte("secret-1")); err != nil {
+		t.Fatalf("AddKey k1 failed: %v", err)
+	}
+	if err := ring.SetActive("k1"); err != nil {
+		t.Fatalf("SetActive k1 failed: %v", err)
+	}
+	m := jwtutil.NewJWTManagerWithKeyRing(ring, time.Minute, "jwt_token")
+
+	oldToken, err := m.GenerateToken(jwt.MapClaims{"user_id": 1})
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err := ring.AddKey("k2", jwt.SigningMethodES256, ecKey); err != nil {
+		t.Fatalf("AddKey k2 failed: %v", err)
+	}
+	if err := ring.SetActive("k2"); err != nil {
+		t.Fatalf("SetActive k2 failed: %v", err)
+	}
+
+	newToken, _ := m.GenerateToken(jwt.MapClaims{"user_id": 1})
+	parsed, _, _ := jwt.NewParser().ParseUnverified(newToken, jwt.MapClaims{})
+	if parsed.Header["kid"] != "k2" {
+		t.Errorf("expected kid k2, got %v", parsed.Header["kid"])
+	}
+
+	if _, err := m.ParseToken(oldToken); err != nil {
+		t.Fatalf("old token should still verify: %v", err)
+	}
+	if _, err := m.ParseToken(newToken); err != nil {
+		t.Fatalf("new token should verify: %v", err)
+	}
+
+	if err := ring.Retire("k2"); !errors.Is(err, jwtutil.ErrKeyIsActive) {
+		t.Errorf("expected ErrKeyIsActive, got %v", err)
+	}
+	if err := ring.Retire("k1"); err != nil {
+		t.Fatalf("Retire k1 failed: %v", err)
+	}
+	if _, err := m.ParseToken(oldToken); err == nil {
+		t.Fatalf("token signed by retired key should be rejected")
+	}
+}
+
+func TestKeyRingVerifyOnlyKeyCannotBeActive(t *testing.T) {
+	ecKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	ring := jwtutil.NewKeyRing()
+	if err := ring.AddKey("pub", jwt.SigningMethodES256, &ecKey.PublicKey); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+	if err := ring.SetActive("pub"); !errors.Is(err, jwtutil.ErrKeyNotSigner) {
+		t.Errorf("expected ErrKeyNotSigner, got %v", err)
+	}
+}
@@ -0,0 +1,125 @@
+package unitTestForUtils
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+// 各非对称算法：私钥签发，公钥校验
+func TestAsymmetricSignAndVerify(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	p384, _ := ecdsa.GenerateKey(elliptic.P384(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+
+	cases := []struct {
+		method jwt.SigningMethod
+		key    crypto.Signer
+	}{
+		{jwt.SigningMethodRS256, rsaKey},
+		{jwt.SigningMethodPS256, rsaKey},
+		{jwt.SigningMethodES256, p256},
+		{jwt.SigningMethodES384, p384},
+		{jwt.SigningMethodEdDSA, edKey},
+	}
+
+	for _, c := range cases {
+		t.Run(c.method.Alg(), func(t *testing.T) {
+			signer, err := jwtutil.NewJWTManagerWithKey(c.method, c.key, time.Minute, "jwt_token")
+			if err != nil {
+				t.Fatalf("NewJWTManagerWithKey failed: %v", err)
+			}
+			token, err := signer.GenerateToken(jwt.MapClaims{"user_id": 1})
+			if err != nil {
+				t.Fatalf("GenerateToken failed: %v", err)
+			}
+
+			verifier, err := jwtutil.NewJWTVerifier(c.method, c.key.Public(), "jwt_token")
+			if err != nil {
+				t.Fatalf("NewJWTVerifier failed: %v", err)
+			}
+			if _, err := verifier.ParseToken(token); err != nil {
+				t.Fatalf("ParseToken failed: %v", err)
+			}
+			if _, err := verifier.GenerateToken(jwt.MapClaims{}); err != jwtutil.ErrNoSigningKey {
+				t.Errorf("verifier should not sign, got %v", err)
+			}
+		})
+	}
+}
+
+// alg 头部与配置不一致时必须拒绝（防止算法混淆）
+func TestParseTokenRejectsOtherAlg(t *testing.T) {
+	rsaKey, _ := rsa.GenerateKey(rand.Reader, 2048)
+	rs256, _ := jwtutil.NewJWTManagerWithKey(jwt.SigningMethodRS256, rsaKey, time.Minute, "jwt_token")
+	ps256, _ := jwtutil.NewJWTManagerWithKey(jwt.SigningMethodPS256, rsaKey, time.Minute, "jwt_token")
+
+	token, err := ps256.GenerateToken(jwt.MapClaims{})
+	if err != nil {
+		t.Fatalf("GenerateToken failed: %v", err)
+	}
+	if _, err := rs256.ParseToken(token); err == nil {
+		t.Fatalf("expected PS256 token to be rejected by RS256 manager")
+	}
+
+	hs := newTestManager()
+	hsToken, _ := hs.GenerateToken(jwt.MapClaims{})
+	if _, err := rs256.ParseToken(hsToken); err == nil {
+		t.Fatalf("expected HS256 token to be rejected by RS256 manager")
+	}
+}
+
+func TestKeyMethodMismatch(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if _, err := jwtutil.NewJWTManagerWithKey(jwt.SigningMethodES384, p256, time.Minute, "jwt_token"); err == nil {
+		t.Errorf("expected curve mismatch error")
+	}
+	if _, err := jwtutil.NewJWTManagerWithKey(jwt.SigningMethodRS256, p256, time.Minute, "jwt_token"); err == nil {
+		t.Errorf("expected key type mismatch error")
+	}
+}
+
+// opaqueSigner 模拟 KMS/HSM 等只暴露 crypto.Signer 的密钥
+type opaqueSigner struct{ crypto.Signer }
+
+// nil 私钥与 golang-jwt 无法直接使用的 Signer 在构造时就报错
+func TestNewJWTManagerWithKeyRejectsUnusableKeys(t *testing.T) {
+	p256, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	_, edKey, _ := ed25519.GenerateKey(rand.Reader)
+	var typedNil *rsa.PrivateKey
+
+	cases := []struct {
+		name   string
+		method jwt.SigningMethod
+		key    crypto.Signer
+		want   error
+	}{
+		{"nil", jwt.SigningMethodRS256, nil, jwtutil.ErrNilKey},
+		{"typed nil", jwt.SigningMethodRS256, typedNil, jwtutil.ErrNilKey},
+		{"opaque ES256", jwt.SigningMethodES256, opaqueSigner{p256}, jwtutil.ErrKeyMismatch},
+	}
+	for _, c := range cases {
+		if _, err := jwtutil.NewJWTManagerWithKey(c.method, c.key, time.Minute, "jwt_token"); !errors.Is(err, c.want) {
+			t.Errorf("%s: expected %v, got %v", c.name, c.want, err)
+		}
+	}
+
+	// EdDSA 接受任意 crypto.Signer
+	m, err := jwtutil.NewJWTManagerWithKey(jwt.SigningMethodEdDSA, opaqueSigner{edKey}, time.Minute, "jwt_token")
+	if err != nil {
+		t.Fatalf("opaque Ed25519 signer should be accepted: %v", err)
+	}
+	if _, err := m.GenerateToken(jwt.MapClaims{}); err != nil {
+		t.Fatalf("GenerateToken with opaque Ed25519 signer failed: %v", err)
+	}
+}
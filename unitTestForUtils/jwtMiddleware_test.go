@@ -0,0 +1,86 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func claimsEchoHandler(t *testing.T) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		claims, ok := jwtutil.ClaimsFromContext(r.Context())
+		if !ok {
+			t.Errorf("claims missing from context")
+		}
+		w.Write([]byte(claims["role"].(string)))
+	})
+}
+
+func TestMiddlewareTokenSources(t *testing.T) {
+	m := newTestManager()
+	token, _ := m.GenerateToken(jwt.MapClaims{"role": "admin"})
+	h := m.Middleware(jwtutil.MiddlewareConfig{
+		Extractors: []jwtutil.TokenExtractor{
+			jwtutil.FromAuthHeader(),
+			jwtutil.FromCookie(m.CookieName),
+			jwtutil.FromQuery("access_token"),
+		},
+	})(claimsEchoHandler(t))
+
+	requests := map[string]*http.Request{}
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	requests["header"] = r
+	r = httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: m.CookieName, Value: token})
+	requests["cookie"] = r
+	requests["query"] = httptest.NewRequest(http.MethodGet, "/?access_token="+token, nil)
+
+	for name, r := range requests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		if w.Code != http.StatusOK || w.Body.String() != "admin" {
+			t.Errorf("%s: expected 200 admin, got %d %s", name, w.Code, w.Body.String())
+		}
+	}
+}
+
+func TestMiddlewareRejects(t *testing.T) {
+	m := newTestManager()
+	h := m.Middleware(jwtutil.MiddlewareConfig{})(claimsEchoHandler(t))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("missing token: expected 401, got %d", w.Code)
+	}
+
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer garbage")
+	w = httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Errorf("bad token: expected 401, got %d", w.Code)
+	}
+}
+
+func TestMiddlewareCustomError(t *testing.T) {
+	m := newTestManager()
+	var got error
+	h := m.Middleware(jwtutil.MiddlewareConfig{
+		OnError: func(w http.ResponseWriter, r *http.Request, err error) {
+			got = err
+			http.Redirect(w, r, "/login", http.StatusFound)
+		},
+	})(claimsEchoHandler(t))
+
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	if w.Code != http.StatusFound || !errors.Is(got, jwtutil.ErrNoToken) {
+		t.Errorf("expected custom redirect with ErrNoToken, got %d %v", w.Code, got)
+	}
+}
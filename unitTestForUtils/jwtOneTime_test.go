@@ -0,0 +1,87 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func TestOneTimeTokenSingleUse(t *testing.T) {
+	ott := jwtutil.NewOneTimeTokens(newTestManager(), jwtutil.NewMemoryOneTimeStore())
+	token, err := ott.Issue("email_verify", "u1", time.Hour, nil, nil)
+	if err != nil {
+		t.Fatalf("Issue failed: %v", err)
+	}
+
+	if _, err := ott.Consume(token, "password_reset", nil); !errors.Is(err, jwtutil.ErrWrongPurpose) {
+		t.Fatalf("expected ErrWrongPurpose, got %v", err)
+	}
+
+	// 并发消费只有一次成功
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	success := 0
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := ott.Consume(token, "email_verify", nil); err == nil {
+				mu.Lock()
+				success++
+				mu.Unlock()
+			} else if !errors.Is(err, jwtutil.ErrTokenAlreadyUsed) {
+				t.Errorf("unexpected error: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+	if success != 1 {
+		t.Fatalf("expected exactly one successful consume, got %d", success)
+	}
+}
+
+// 绑定密码哈希：密码修改后 token 失效
+func TestOneTimeTokenBinding(t *testing.T) {
+	ott := jwtutil.NewOneTimeTokens(newTestManager(), jwtutil.NewMemoryOneTimeStore())
+	oldHash := []byte("$2a$10$old-password-hash")
+	token, _ := ott.Issue("password_reset", "u1", time.Hour, oldHash, nil)
+
+	if _, err := ott.Consume(token, "password_reset", []byte("$2a$10$new-password-hash")); !errors.Is(err, jwtutil.ErrBindingMismatch) {
+		t.Fatalf("expected ErrBindingMismatch, got %v", err)
+	}
+	claims, err := ott.Consume(token, "password_reset", oldHash)
+	if err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+	if claims["sub"] != "u1" {
+		t.Errorf("sub mismatch: %v", claims["sub"])
+	}
+}
+
+// 一次性 token 不能当作会话 token 通过中间件
+func TestOneTimeTokenRejectedAsSession(t *testing.T) {
+	m := newTestManager()
+	ott := jwtutil.NewOneTimeTokens(m, jwtutil.NewMemoryOneTimeStore())
+	token, _ := ott.Issue("password_reset", "u1", time.Hour, nil, nil)
+
+	h := m.Middleware(jwtutil.MiddlewareConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+	if _, err := m.ParseToken(token); !errors.Is(err, jwtutil.ErrWrongTokenType) {
+		t.Fatalf("expected ErrWrongTokenType, got %v", err)
+	}
+
+	if _, err := ott.Consume(token, "password_reset", nil); err != nil {
+		t.Fatalf("Consume failed: %v", err)
+	}
+}
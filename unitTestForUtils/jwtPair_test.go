@@ -0,0 +1,120 @@
+package unitTestForUtils
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func newTestPairManager() *jwtutil.PairManager {
+	access := jwtutil.NewJWTManager("access-secret", time.Minute, "access_token")
+	refresh := jwtutil.NewJWTManager("refresh-secret", time.Hour, "refresh_token")
+	return jwtutil.NewPairManager(access, refresh, jwtutil.NewMemoryRefreshStore())
+}
+
+// 每次刷新都轮换 refresh token，claims 保持不变
+func TestRefreshPairRotates(t *testing.T) {
+	p := newTestPairManager()
+	pair, err := p.IssuePair(jwt.MapClaims{"user_id": 7})
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	next, err := p.RefreshPair(pair.RefreshToken)
+	if err != nil {
+		t.Fatalf("RefreshPair failed: %v", err)
+	}
+	if next.RefreshToken == pair.RefreshToken {
+		t.Fatalf("refresh token should rotate")
+	}
+	claims, err := p.Access.ParseToken(next.AccessToken)
+	if err != nil {
+		t.Fatalf("new access token invalid: %v", err)
+	}
+	if claims["user_id"] != float64(7) {
+		t.Errorf("user_id not carried over, got %v", claims["user_id"])
+	}
+
+	if _, err := p.RefreshPair(next.AccessToken); err == nil {
+		t.Errorf("access token must not be accepted as refresh token")
+	}
+}
+
+// 重放已使用的 refresh token 会撤销整个家族
+func TestRefreshReuseRevokesFamily(t *testing.T) {
+	p := newTestPairManager()
+	pair, _ := p.IssuePair(jwt.MapClaims{"user_id": 7})
+	next, _ := p.RefreshPair(pair.RefreshToken)
+
+	if _, err := p.RefreshPair(pair.RefreshToken); !errors.Is(err, jwtutil.ErrRefreshTokenReused) {
+		t.Fatalf("expected ErrRefreshTokenReused, got %v", err)
+	}
+	if _, err := p.RefreshPair(next.RefreshToken); !errors.Is(err, jwtutil.ErrTokenFamilyRevoked) {
+		t.Fatalf("expected ErrTokenFamilyRevoked for sibling token, got %v", err)
+	}
+}
+
+func TestRefreshHandler(t *testing.T) {
+	p := newTestPairManager()
+	pair, _ := p.IssuePair(jwt.MapClaims{"user_id": 7})
+
+	form := url.Values{"refresh_token": {pair.RefreshToken}}
+	r := httptest.NewRequest(http.MethodPost, "/refresh", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	w := httptest.NewRecorder()
+	p.RefreshHandler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var next jwtutil.TokenPair
+	if err := json.Unmarshal(w.Body.Bytes(), &next); err != nil || next.AccessToken == "" {
+		t.Fatalf("bad response body: %s", w.Body.String())
+	}
+
+	// 使用 Cookie 时写回新的 Cookie
+	r = httptest.NewRequest(http.MethodPost, "/refresh", nil)
+	r.AddCookie(&http.Cookie{Name: "refresh_token", Value: next.RefreshToken})
+	w = httptest.NewRecorder()
+	p.RefreshHandler().ServeHTTP(w, r)
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if n := len(w.Result().Cookies()); n != 2 {
+		t.Errorf("expected access and refresh cookies, got %d", n)
+	}
+}
+
+// Access 与 Refresh 共用同一个管理器时，refresh token 也不能当作 access token
+func TestRefreshTokenRejectedAsAccess(t *testing.T) {
+	m := jwtutil.NewJWTManager("shared-secret", time.Hour, "auth_token")
+	p := jwtutil.NewPairManager(m, m, jwtutil.NewMemoryRefreshStore())
+	pair, err := p.IssuePair(jwt.MapClaims{"sub": "7"})
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+
+	if _, err := m.ParseToken(pair.RefreshToken); !errors.Is(err, jwtutil.ErrWrongTokenType) {
+		t.Fatalf("expected ErrWrongTokenType, got %v", err)
+	}
+	h := m.Middleware(jwtutil.MiddlewareConfig{})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.Header.Set("Authorization", "Bearer "+pair.RefreshToken)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	if w.Code != http.StatusUnauthorized {
+		t.Fatalf("expected 401, got %d", w.Code)
+	}
+
+	// refresh 端点本身不受影响
+	if _, err := p.RefreshPair(pair.RefreshToken); err != nil {
+		t.Fatalf("RefreshPair failed: %v", err)
+	}
+}
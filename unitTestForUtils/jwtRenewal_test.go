@@ -0,0 +1,146 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+// 构造一个签发于 age 之前、剩余 remaining 的 token
+func agedToken(m *jwtutil.JWTManager, age, remaining time.Duration, extra jwt.MapClaims) string {
+	now := time.Now()
+	claims := jwt.MapClaims{"jti": "session-1", "iat": now.Add(-age).Unix(), "exp": now.Add(remaining).Unix()}
+	for k, v := range extra {
+		claims[k] = v
+	}
+	s, _ := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(m.Secret)
+	return s
+}
+
+func serveWithCookie(h http.Handler, m *jwtutil.JWTManager, token string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodGet, "/", nil)
+	r.AddCookie(&http.Cookie{Name: m.CookieName, Value: token})
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestSlidingRenewal(t *testing.T) {
+	m := newTestManager() // TokenDuration = 1 分钟
+	policy := &jwtutil.RenewalPolicy{Threshold: 0.5, MaxAge: time.Hour}
+	h := m.Middleware(jwtutil.MiddlewareConfig{Renewal: policy})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// 只用掉 1/6 生命周期，不续签
+	w := serveWithCookie(h, m, agedToken(m, 10*time.Second, 50*time.Second, nil))
+	if len(w.Result().Cookies()) != 0 {
+		t.Fatalf("fresh token should not be renewed")
+	}
+
+	// 超过一半，续签：换新 jti，sid 与 auth_time 保留会话
+	w = serveWithCookie(h, m, agedToken(m, 40*time.Second, 20*time.Second, nil))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected renewed cookie, got %d cookies", len(cookies))
+	}
+	claims, err := m.ParseToken(cookies[0].Value)
+	if err != nil {
+		t.Fatalf("renewed token invalid: %v", err)
+	}
+	if claims[jwtutil.SessionClaim] != "session-1" {
+		t.Errorf("sid should carry the original jti, got %v", claims[jwtutil.SessionClaim])
+	}
+	if claims["jti"] == "session-1" {
+		t.Errorf("renewed token should get a fresh jti")
+	}
+	if _, ok := claims[jwtutil.AuthTimeClaim]; !ok {
+		t.Errorf("auth_time should be recorded")
+	}
+}
+
+func TestSlidingRenewalMaxAge(t *testing.T) {
+	m := newTestManager()
+	policy := &jwtutil.RenewalPolicy{Threshold: 0.5, MaxAge: time.Hour}
+	h := m.Middleware(jwtutil.MiddlewareConfig{Renewal: policy})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	// 距离上限还剩 30 秒：续签，但 exp 被截断到上限
+	authTime := time.Now().Add(-time.Hour + 30*time.Second).Unix()
+	w := serveWithCookie(h, m, agedToken(m, 40*time.Second, 20*time.Second, jwt.MapClaims{"auth_time": authTime}))
+	cookies := w.Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected renewed cookie")
+	}
+	claims, _ := m.ParseToken(cookies[0].Value)
+	if exp := int64(claims["exp"].(float64)); exp > authTime+int64(time.Hour/time.Second) {
+		t.Errorf("exp %d exceeds max session age", exp)
+	}
+
+	// 已到上限：不再续签
+	authTime = time.Now().Add(-2 * time.Hour).Unix()
+	w = serveWithCookie(h, m, agedToken(m, 40*time.Second, 20*time.Second, jwt.MapClaims{"auth_time": authTime}))
+	if len(w.Result().Cookies()) != 0 {
+		t.Errorf("session past max age should not be renewed")
+	}
+}
+
+type recordingRevocationStore struct {
+	*jwtutil.MemoryRevocationStore
+	expireAt time.Time
+}
+
+func (s *recordingRevocationStore) RevokeToken(jti string, expireAt time.Time) error {
+	s.expireAt = expireAt
+	return s.MemoryRevocationStore.RevokeToken(jti, expireAt)
+}
+
+// 撤销续签前的旧 token：续签后的 token 同样失效，记录保留到续签出的 token 过期之后
+func TestRevokeRenewedSession(t *testing.T) {
+	store := &recordingRevocationStore{MemoryRevocationStore: jwtutil.NewMemoryRevocationStore(time.Minute)}
+	defer store.Stop()
+	m := newTestManager()
+	m.SetRevocationStore(store)
+	policy := &jwtutil.RenewalPolicy{Threshold: 0.5} // 不限制会话时长
+	h := m.Middleware(jwtutil.MiddlewareConfig{Renewal: policy})(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	old := agedToken(m, 40*time.Second, 20*time.Second, nil)
+	cookies := serveWithCookie(h, m, old).Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected renewed cookie")
+	}
+	renewed := cookies[0].Value
+	claims, err := m.ParseToken(renewed)
+	if err != nil {
+		t.Fatalf("renewed token invalid: %v", err)
+	}
+
+	if err := m.RevokeToken(old); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := m.ParseToken(renewed); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("renewed token should share the revocation, got %v", err)
+	}
+	exp, _ := claims.GetExpirationTime()
+	if store.expireAt.Before(exp.Time) {
+		t.Errorf("revocation record expires at %v, before renewed token exp %v", store.expireAt, exp.Time)
+	}
+	if limit := time.Now().Add(2 * m.TokenDuration); store.expireAt.After(limit) {
+		t.Errorf("revocation record should be bounded, expires at %v", store.expireAt)
+	}
+
+	// 反过来撤销续签后的 token，续签前的旧 token 也失效
+	old = agedToken(m, 40*time.Second, 20*time.Second, jwt.MapClaims{"jti": "session-2"})
+	cookies = serveWithCookie(h, m, old).Result().Cookies()
+	if len(cookies) != 1 {
+		t.Fatalf("expected renewed cookie")
+	}
+	if err := m.RevokeToken(cookies[0].Value); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := m.ParseToken(old); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("original token should share the revocation, got %v", err)
+	}
+}
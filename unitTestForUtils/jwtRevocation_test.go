@@ -0,0 +1,110 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func TestRevokeToken(t *testing.T) {
+	store := jwtutil.NewMemoryRevocationStore(time.Minute)
+	defer store.Stop()
+	m := newTestManager()
+	m.SetRevocationStore(store)
+
+	token, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+	other, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+
+	if err := m.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	if _, err := m.ParseToken(token); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked, got %v", err)
+	}
+	if _, err := m.ParseToken(other); err != nil {
+		t.Fatalf("other token should stay valid: %v", err)
+	}
+}
+
+// 撤销某个用户在某时间之前签发的全部 token
+func TestRevokeSubjectBefore(t *testing.T) {
+	store := jwtutil.NewMemoryRevocationStore(time.Minute)
+	defer store.Stop()
+	m := newTestManager()
+	m.SetRevocationStore(store)
+
+	old, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1", "iat": time.Now().Add(-10 * time.Second).Unix()})
+	otherUser, _ := m.GenerateToken(jwt.MapClaims{"sub": "u2", "iat": time.Now().Add(-10 * time.Second).Unix()})
+	m.RevokeSubject("u1", time.Now().Add(-5*time.Second))
+
+	later, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+
+	if _, err := m.ParseToken(old); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked, got %v", err)
+	}
+	if _, err := m.ParseToken(otherUser); err != nil {
+		t.Fatalf("other user's token should stay valid: %v", err)
+	}
+	if _, err := m.ParseToken(later); err != nil {
+		t.Fatalf("token issued after cutoff should stay valid: %v", err)
+	}
+}
+
+// 文件实现：重新打开后撤销记录仍然有效
+func TestFileRevocationStorePersists(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "revoked.jsonl")
+	m := newTestManager()
+
+	store, err := jwtutil.NewFileRevocationStore(path, time.Minute)
+	if err != nil {
+		t.Fatalf("NewFileRevocationStore failed: %v", err)
+	}
+	m.SetRevocationStore(store)
+	token, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+	if err := m.RevokeToken(token); err != nil {
+		t.Fatalf("RevokeToken failed: %v", err)
+	}
+	store.RevokeToken("already-expired", time.Now().Add(-time.Second))
+	store.Close()
+
+	reopened, err := jwtutil.NewFileRevocationStore(path, time.Minute)
+	if err != nil {
+		t.Fatalf("reopen failed: %v", err)
+	}
+	defer reopened.Close()
+	m.SetRevocationStore(reopened)
+	if _, err := m.ParseToken(token); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("expected ErrTokenRevoked after reopen, got %v", err)
+	}
+	if revoked, _ := reopened.IsRevoked("already-expired", "", time.Time{}); revoked {
+		t.Errorf("expired record should be dropped on load")
+	}
+}
+
+// 与 RevokeSubject 同一秒内、但在其之后签发的 token 不受影响
+func TestRevokeSubjectSameSecond(t *testing.T) {
+	store := jwtutil.NewMemoryRevocationStore(time.Minute)
+	defer store.Stop()
+	clock := clockutil.NewFakeClock(time.Now().Truncate(time.Second).Add(500 * time.Millisecond))
+	m := newTestManager()
+	m.SetClock(clock)
+	m.SetRevocationStore(store)
+
+	before, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+	clock.Advance(time.Millisecond)
+	m.RevokeSubject("u1", clock.Now())
+	clock.Advance(time.Millisecond)
+	after, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1"})
+
+	if _, err := m.ParseToken(before); !errors.Is(err, jwtutil.ErrTokenRevoked) {
+		t.Fatalf("token issued before cutoff should be revoked, got %v", err)
+	}
+	if _, err := m.ParseToken(after); err != nil {
+		t.Fatalf("login right after logout-everywhere should succeed: %v", err)
+	}
+}
@@ -0,0 +1,52 @@
+package unitTestForUtils
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+type userClaims struct {
+	UserID int64  `json:"user_id"`
+	Role   string `json:"role"`
+	jwt.RegisteredClaims
+}
+
+func (c *userClaims) Validate() error {
+	if c.Role == "" {
+		return errors.New("role is required")
+	}
+	return nil
+}
+
+// 自定义 claims：数字按声明类型解码，不丢精度
+func TestTypedClaimsRoundTrip(t *testing.T) {
+	m := newTestManager()
+	const bigID = int64(9007199254740993) // 2^53 + 1，float64 无法精确表示
+
+	token, err := jwtutil.GenerateTokenFor(m, userClaims{UserID: bigID, Role: "admin"})
+	if err != nil {
+		t.Fatalf("GenerateTokenFor failed: %v", err)
+	}
+	claims, err := jwtutil.ParseTokenAs[userClaims](m, token)
+	if err != nil {
+		t.Fatalf("ParseTokenAs failed: %v", err)
+	}
+	if claims.UserID != bigID {
+		t.Errorf("user_id mismatch: expected %d, got %d", bigID, claims.UserID)
+	}
+	if claims.ExpiresAt == nil || claims.ID == "" {
+		t.Errorf("registered claims should be filled, got %+v", claims.RegisteredClaims)
+	}
+}
+
+// 解码后运行 Validate 钩子
+func TestTypedClaimsValidateHook(t *testing.T) {
+	m := newTestManager()
+	token, _ := jwtutil.GenerateTokenFor(m, userClaims{UserID: 1})
+	if _, err := jwtutil.ParseTokenAs[userClaims](m, token); !errors.Is(err, jwtutil.ErrInvalidClaims) {
+		t.Fatalf("expected ErrInvalidClaims, got %v", err)
+	}
+}
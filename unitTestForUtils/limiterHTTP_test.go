@@ -0,0 +1,122 @@
+package unitTestForUtils
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+func TestClientIPKey(t *testing.T) {
+	key, err := limiterUtil.ClientIPKey("10.0.0.0/8", "192.168.1.1")
+	if err != nil {
+		t.Fatalf("ClientIPKey failed: %v", err)
+	}
+	cases := []struct {
+		remote, xff, want string
+	}{
+		{"203.0.113.9:1234", "1.1.1.1", "203.0.113.9"},            // 不可信直连，忽略 XFF
+		{"10.0.0.2:80", "198.51.100.7, 10.0.0.5", "198.51.100.7"}, // 跳过可信代理
+		{"10.0.0.2:80", "6.6.6.6, 198.51.100.7", "198.51.100.7"},  // 伪造的最左侧地址被忽略
+		{"192.168.1.1:80", "", "192.168.1.1"},                     // 没有 XFF
+		{"10.0.0.2:80", "10.0.0.9, 10.0.0.5", "10.0.0.9"},         // 全是可信地址时取最左侧
+		{"10.0.0.2:80", "garbage, 198.51.100.7", "198.51.100.7"},
+	}
+	for _, c := range cases {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.RemoteAddr = c.remote
+		if c.xff != "" {
+			r.Header.Set("X-Forwarded-For", c.xff)
+		}
+		if got, _ := key(r); got != c.want {
+			t.Errorf("remote=%s xff=%q: expected %s, got %s", c.remote, c.xff, c.want, got)
+		}
+	}
+	if _, err := limiterUtil.ClientIPKey("not-an-ip"); err == nil {
+		t.Errorf("expected error for invalid trusted proxy")
+	}
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	kl := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template: limiterUtil.LimiterConfig{StableCap: 2, StableRate: 1, BurstCap: 1, BurstRate: 0.5, FailThreshold: 100, Clock: clock},
+	})
+	defer kl.Stop()
+	h := limiterUtil.RateLimitMiddleware(kl, limiterUtil.RateLimitConfig{Key: limiterUtil.HeaderKey("X-API-Key")})(
+		http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+
+	serve := func(apiKey string) *httptest.ResponseRecorder {
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("X-API-Key", apiKey)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w
+	}
+
+	w := serve("k1")
+	if w.Code != http.StatusOK || w.Header().Get("RateLimit-Limit") != "3" || w.Header().Get("RateLimit-Remaining") != "2" {
+		t.Fatalf("unexpected first response: %d %v", w.Code, w.Header())
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "1" {
+		t.Errorf("expected reset 1s, got %s", got)
+	}
+	serve("k1")
+	serve("k1")
+	w = serve("k1")
+	if w.Code != http.StatusTooManyRequests || w.Header().Get("RateLimit-Remaining") != "0" {
+		t.Fatalf("expected 429 with no remaining, got %d %v", w.Code, w.Header())
+	}
+	if got := w.Header().Get("Retry-After"); got != "1" {
+		t.Errorf("expected Retry-After 1, got %s", got)
+	}
+	if got := w.Header().Get("RateLimit-Reset"); got != "2" {
+		t.Errorf("expected reset 2s (burst refills at 0.5/s), got %s", got)
+	}
+
+	if w := serve("k2"); w.Code != http.StatusOK {
+		t.Errorf("other key should not be limited, got %d", w.Code)
+	}
+	clock.Advance(time.Second)
+	if w := serve("k1"); w.Code != http.StatusOK {
+		t.Errorf("expected k1 to recover after refill, got %d", w.Code)
+	}
+}
+
+func TestRateLimitByClaim(t *testing.T) {
+	m := newTestManager()
+	tb := limiterUtil.NewTripleBucket(1, 0, 0, 0, 100, time.Second)
+	kl := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template: limiterUtil.LimiterConfig{StableCap: 1, FailThreshold: 100},
+	})
+	defer kl.Stop()
+
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {})
+	byUser := m.Middleware(jwtutil.MiddlewareConfig{})(
+		limiterUtil.RateLimitMiddleware(kl, limiterUtil.RateLimitConfig{Key: jwtutil.ClaimKey("sub")})(ok))
+	global := limiterUtil.RateLimitMiddleware(limiterUtil.SharedBucket(tb), limiterUtil.RateLimitConfig{})(ok)
+
+	serve := func(h http.Handler, sub string) int {
+		token, _ := m.GenerateToken(jwt.MapClaims{"sub": sub})
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, r)
+		return w.Code
+	}
+	if serve(byUser, "u1") != http.StatusOK || serve(byUser, "u2") != http.StatusOK {
+		t.Fatalf("first request per user should pass")
+	}
+	if code := serve(byUser, "u1"); code != http.StatusTooManyRequests {
+		t.Fatalf("expected u1 to be limited, got %d", code)
+	}
+
+	if serve(global, "u1") != http.StatusOK || serve(global, "u2") != http.StatusTooManyRequests {
+		t.Fatalf("shared bucket should limit across users")
+	}
+}
@@ -0,0 +1,104 @@
+package unitTestForUtils
+
+import (
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+func TestKeyedLimiterIsolationAndOverride(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	k := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template:  limiterUtil.LimiterConfig{StableCap: 2, StableRate: 1, FailThreshold: 100, Clock: clock},
+		Overrides: map[string]limiterUtil.LimiterConfig{"vip": {StableCap: 5, StableRate: 1, FailThreshold: 100}},
+	})
+	defer k.Stop()
+
+	for i := 0; i < 2; i++ {
+		if !k.Allow("alice") {
+			t.Fatalf("alice request %d should pass", i)
+		}
+	}
+	if k.Allow("alice") {
+		t.Fatalf("alice should be limited")
+	}
+	if !k.Allow("bob") {
+		t.Fatalf("bob should have his own bucket")
+	}
+	for i := 0; i < 5; i++ {
+		if !k.Allow("vip") {
+			t.Fatalf("vip request %d should pass", i)
+		}
+	}
+
+	clock.Advance(time.Second)
+	if !k.Allow("alice") {
+		t.Fatalf("alice should refill via the injected clock")
+	}
+
+	// 运行时覆盖会重建桶
+	k.SetOverride("alice", limiterUtil.LimiterConfig{StableCap: 10, FailThreshold: 100})
+	if got := k.Bucket("alice").Stable.Capacity(); got != 10 {
+		t.Fatalf("expected override capacity 10, got %v", got)
+	}
+}
+
+func TestKeyedLimiterEviction(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	k := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template: limiterUtil.LimiterConfig{StableCap: 1, FailThreshold: 100, Clock: clock},
+		Shards:   1,
+		MaxKeys:  3,
+		IdleTTL:  time.Minute,
+	})
+	defer k.Stop()
+
+	for _, key := range []string{"a", "b", "c"} {
+		k.Allow(key)
+	}
+	k.Bucket("a") // a 变为最近访问
+	k.Allow("d")  // 淘汰最久未访问的 b
+	if k.Len() != 3 {
+		t.Fatalf("expected 3 keys, got %d", k.Len())
+	}
+	if k.Allow("a") {
+		t.Fatalf("a should keep its drained bucket")
+	}
+	if !k.Allow("b") {
+		t.Fatalf("b was evicted and should start with a fresh bucket")
+	}
+
+	clock.Advance(2 * time.Minute)
+	k.Cleanup()
+	if k.Len() != 0 {
+		t.Fatalf("expected idle keys to be evicted, %d left", k.Len())
+	}
+}
+
+func TestKeyedLimiterConcurrent(t *testing.T) {
+	k := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template: limiterUtil.LimiterConfig{StableCap: 100, FailThreshold: 1000},
+		MaxKeys:  64,
+		IdleTTL:  time.Minute,
+	})
+	defer k.Stop()
+
+	var wg sync.WaitGroup
+	for g := 0; g < 8; g++ {
+		wg.Add(1)
+		go func(g int) {
+			defer wg.Done()
+			for i := 0; i < 500; i++ {
+				k.Allow(fmt.Sprintf("key-%d", (g*31+i)%200))
+			}
+		}(g)
+	}
+	wg.Wait()
+	if n := k.Len(); n > 64 {
+		t.Fatalf("MaxKeys not enforced: %d keys", n)
+	}
+}
@@ -0,0 +1,76 @@
+package unitTestForUtils
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+func TestBucketReserve(t *testing.T) {
+	clock := clockutil.NewFakeClock(time.Time{})
+	b := limiterUtil.NewBucket(4, 2)
+	b.SetClock(clock)
+
+	if r := b.Reserve(4); !r.OK() || r.Delay() != 0 {
+		t.Fatalf("expected immediate reservation, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	r := b.Reserve(3)
+	if !r.OK() || r.Delay() != 1500*time.Millisecond {
+		t.Fatalf("expected 1.5s delay, got ok=%v delay=%v", r.OK(), r.Delay())
+	}
+	clock.Advance(time.Second)
+	if r.Delay() != 500*time.Millisecond {
+		t.Fatalf("expected 0.5s remaining, got %v", r.Delay())
+	}
+
+	// 取消后令牌归还：透支的 3 个还回来，1s 补充了 2 个
+	r.Cancel()
+	if got := b.Tokens(); got != 2 {
+		t.Fatalf("expected 2 tokens after cancel, got %v", got)
+	}
+	r.Cancel() // 重复取消无效
+	if got := b.Tokens(); got != 2 {
+		t.Fatalf("double cancel changed tokens: %v", got)
+	}
+
+	if r := b.Reserve(5); r.OK() {
+		t.Fatalf("reservation above capacity should fail")
+	}
+}
+
+func TestBucketWait(t *testing.T) {
+	b := limiterUtil.NewBucket(1, 20)
+	ctx := context.Background()
+	if err := b.Wait(ctx, 1); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	start := time.Now()
+	if err := b.Wait(ctx, 1); err != nil {
+		t.Fatalf("Wait failed: %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < 40*time.Millisecond {
+		t.Fatalf("expected to wait ~50ms, waited %v", elapsed)
+	}
+
+	// 截止时间不够时立即返回，并归还令牌
+	short, cancel := context.WithTimeout(ctx, 30*time.Millisecond)
+	defer cancel()
+	start = time.Now()
+	if err := b.Wait(short, 1); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected DeadlineExceeded, got %v", err)
+	}
+	if time.Since(start) > 20*time.Millisecond {
+		t.Fatalf("Wait should fail fast when the deadline is too short")
+	}
+	if b.Tokens() < 0 {
+		t.Fatalf("tokens should be returned after failed wait, got %v", b.Tokens())
+	}
+
+	if err := b.Wait(ctx, 2); !errors.Is(err, limiterUtil.ErrExceedsCapacity) {
+		t.Fatalf("expected ErrExceedsCapacity, got %v", err)
+	}
+}
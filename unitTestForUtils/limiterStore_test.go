@@ -0,0 +1,273 @@
+package unitTestForUtils
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/redis/go-redis/v9"
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+func newTestRedisStore(t *testing.T) (*limiterUtil.RedisStore, *miniredis.Miniredis) {
+	mr := miniredis.RunT(t)
+	client := redis.NewClient(&redis.Options{Addr: mr.Addr(), MaxRetries: -1})
+	t.Cleanup(func() { client.Close() })
+	return limiterUtil.NewRedisStore(client, "rl:"), mr
+}
+
+func TestStoreTakeTokens(t *testing.T) {
+	redisStore, mr := newTestRedisStore(t)
+	memStore := limiterUtil.NewMemoryStore()
+	clock := clockutil.NewFakeClock(time.Unix(1700000000, 0))
+	memStore.SetClock(clock)
+	mr.SetTime(clock.Now())
+
+	ctx := context.Background()
+	for name, s := range map[string]limiterUtil.Store{"memory": memStore, "redis": redisStore} {
+		for i := 0; i < 3; i++ {
+			if ok, _, err := s.TakeTokens(ctx, "k", 3, 1, 1); !ok || err != nil {
+				t.Fatalf("%s: take %d failed: %v %v", name, i, ok, err)
+			}
+		}
+		ok, remaining, err := s.TakeTokens(ctx, "k", 3, 1, 1)
+		if ok || err != nil || remaining != 0 {
+			t.Fatalf("%s: expected empty bucket, got %v %v %v", name, ok, remaining, err)
+		}
+	}
+
+	// 推进 1.5 秒补充 1.5 个令牌
+	clock.Advance(1500 * time.Millisecond)
+	mr.SetTime(clock.Now())
+	for name, s := range map[string]limiterUtil.Store{"memory": memStore, "redis": redisStore} {
+		ok, remaining, err := s.TakeTokens(ctx, "k", 3, 1, 1)
+		if !ok || err != nil || remaining != 0.5 {
+			t.Fatalf("%s: expected refill to 1.5, got %v %v %v", name, ok, remaining, err)
+		}
+	}
+}
+
+func TestStoreTakeWindow(t *testing.T) {
+	redisStore, mr := newTestRedisStore(t)
+	memStore := limiterUtil.NewMemoryStore()
+	clock := clockutil.NewFakeClock(time.Unix(1700000000, 0))
+	memStore.SetClock(clock)
+	redisStore.SetClock(clock)
+	mr.SetTime(clock.Now())
+
+	ctx := context.Background()
+	for name, s := range map[string]limiterUtil.Store{"memory": memStore, "redis": redisStore} {
+		if ok, n, _ := s.TakeWindow(ctx, "w", 5, time.Minute, 4); !ok || n != 4 {
+			t.Fatalf("%s: expected count 4, got %v %d", name, ok, n)
+		}
+		if ok, n, _ := s.TakeWindow(ctx, "w", 5, time.Minute, 2); ok || n != 4 {
+			t.Fatalf("%s: expected rejection at 4, got %v %d", name, ok, n)
+		}
+	}
+	// 窗口 key 由客户端计算并作为 KEYS[1] 传入（Redis Cluster 要求脚本访问的 key 都经 KEYS 声明）
+	if keys := mr.Keys(); len(keys) != 1 || keys[0] != "rl:w:28333333" {
+		t.Fatalf("unexpected redis keys %v", keys)
+	}
+	clock.Advance(time.Minute)
+	mr.SetTime(clock.Now())
+	for name, s := range map[string]limiterUtil.Store{"memory": memStore, "redis": redisStore} {
+		if ok, n, _ := s.TakeWindow(ctx, "w", 5, time.Minute, 2); !ok || n != 2 {
+			t.Fatalf("%s: expected new window, got %v %d", name, ok, n)
+		}
+	}
+}
+
+// 多个副本共享 Redis 配额，总放行量不超过容量
+func TestDistributedBucketClusterQuota(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	var allowed int64
+	var wg sync.WaitGroup
+	for replica := 0; replica < 4; replica++ {
+		b := limiterUtil.NewDistributedBucket(store, "api", 20, 0.001)
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 20; i++ {
+				if b.TryTake(1) {
+					atomic.AddInt64(&allowed, 1)
+				}
+			}
+		}()
+	}
+	wg.Wait()
+	if allowed != 20 {
+		t.Fatalf("expected exactly 20 allowed across replicas, got %d", allowed)
+	}
+}
+
+func TestDistributedBucketFallback(t *testing.T) {
+	store, mr := newTestRedisStore(t)
+	clock := clockutil.NewFakeClock(time.Time{})
+	b := limiterUtil.NewDistributedBucket(store, "api", 5, 0.001)
+	b.Fallback = limiterUtil.NewBucket(2, 0)
+	b.SetClock(clock)
+
+	mr.Close()
+	if !b.TryTake(1) || !b.Degraded() || b.LastError() == nil {
+		t.Fatalf("expected fallback after backend failure")
+	}
+	if !b.TryTake(1) || b.TryTake(1) {
+		t.Fatalf("fallback bucket should enforce its own capacity")
+	}
+
+	if err := mr.Restart(); err != nil {
+		t.Fatalf("restart failed: %v", err)
+	}
+	clock.Advance(2 * time.Second)
+	if !b.TryTake(1) || b.Degraded() {
+		t.Fatalf("expected to recover once the backend is back")
+	}
+}
+
+func TestLimiterClusterQuota(t *testing.T) {
+	store, _ := newTestRedisStore(t)
+	cfg := limiterUtil.LimiterConfig{
+		StableCap:     10,
+		FailThreshold: 100,
+		QueueCleanup:  time.Hour,
+		Quota:         limiterUtil.NewDistributedBucket(store, "jobs", 3, 0.001),
+	}
+	a := limiterUtil.NewLimiter[int](cfg)
+	cfg.Quota = limiterUtil.NewDistributedBucket(store, "jobs", 3, 0.001)
+	b := limiterUtil.NewLimiter[int](cfg)
+	defer a.Stop()
+	defer b.Stop()
+
+	taken := 0
+	for i := 0; i < 3; i++ {
+		for _, l := range []*limiterUtil.Limiter[int]{a, b} {
+			if l.Submit(i) == limiterUtil.StateTaken {
+				taken++
+			}
+		}
+	}
+	if taken != 3 {
+		t.Fatalf("expected cluster quota of 3, got %d", taken)
+	}
+}
+
+// denyStore 模拟配额已用完的集群存储，记录调用次数
+type denyStore struct {
+	allow atomic.Bool
+	calls atomic.Int32
+}
+
+func (s *denyStore) TakeTokens(ctx context.Context, key string, capacity, rate, n float64) (bool, float64, error) {
+	s.calls.Add(1)
+	if s.allow.Load() {
+		return true, capacity - n, nil
+	}
+	return false, 0, nil
+}
+
+func (s *denyStore) TakeWindow(ctx context.Context, key string, limit int64, window time.Duration, n int64) (bool, int64, error) {
+	return false, limit, nil
+}
+
+// 集群配额拒绝时归还本地令牌
+func TestLimiterQuotaDenialRefundsLocal(t *testing.T) {
+	store := &denyStore{}
+	l := limiterUtil.NewLimiter[int](limiterUtil.LimiterConfig{
+		StableCap:     5,
+		FailThreshold: 100,
+		QueueCleanup:  time.Hour,
+		Quota:         limiterUtil.NewDistributedBucket(store, "jobs", 10, 1),
+	})
+	defer l.Stop()
+
+	for i := 0; i < 5; i++ {
+		if st := l.Submit(i); st == limiterUtil.StateTaken {
+			t.Fatalf("submit %d should not be taken while the quota denies", i)
+		}
+	}
+	// 配额恢复后本地 5 个令牌仍然可用
+	store.allow.Store(true)
+	for i := 0; i < 5; i++ {
+		if st := l.Submit(i); st != limiterUtil.StateTaken {
+			t.Fatalf("local token %d was lost to a quota denial, got state %v", i, st)
+		}
+	}
+}
+
+// 连续的配额拒绝计入熔断
+func TestLimiterQuotaDenialTripsBreaker(t *testing.T) {
+	store := &denyStore{}
+	l := limiterUtil.NewLimiter[int](limiterUtil.LimiterConfig{
+		StableCap:     5,
+		FailThreshold: 3,
+		RejectDur:     time.Minute,
+		QueueCleanup:  time.Hour,
+		Quota:         limiterUtil.NewDistributedBucket(store, "jobs", 10, 1),
+	})
+	defer l.Stop()
+
+	for i := 0; i < 3; i++ {
+		l.Submit(i)
+	}
+	if st := l.Submit(3); st != limiterUtil.StateDiscarded {
+		t.Fatalf("expected breaker to trip after repeated quota denials, got %v", st)
+	}
+	if n := store.calls.Load(); n != 3 {
+		t.Fatalf("rejected submits should not reach the store, got %d calls", n)
+	}
+}
+
+// worker 按配额补足时间退避，而不是每 10ms 访问一次存储
+func TestLimiterQuotaDenialBacksOff(t *testing.T) {
+	store := &denyStore{}
+	l := limiterUtil.NewLimiter[int](limiterUtil.LimiterConfig{
+		StableCap:      5,
+		FailThreshold:  100,
+		QueueCleanup:   time.Hour,
+		WorkerInterval: 10 * time.Millisecond,
+		Quota:          limiterUtil.NewDistributedBucket(store, "jobs", 10, 1),
+	})
+	l.Submit(1)
+	l.Start()
+	time.Sleep(300 * time.Millisecond)
+	l.Stop()
+
+	// 速率 1/s、剩余 0：约 1s 后才重试
+	if n := store.calls.Load(); n > 3 {
+		t.Fatalf("worker polled the store %d times in 300ms", n)
+	}
+}
+
+// 窗口作为本地令牌源时，集群配额拒绝同样会把次数退回窗口
+func TestLimiterQuotaDenialRefundsWindow(t *testing.T) {
+	sources := map[string]func() limiterUtil.TokenSource{
+		"fixed":   func() limiterUtil.TokenSource { return limiterUtil.NewFixedWindow(5, time.Hour) },
+		"log":     func() limiterUtil.TokenSource { return limiterUtil.NewSlidingWindowLog(5, time.Hour) },
+		"counter": func() limiterUtil.TokenSource { return limiterUtil.NewSlidingWindowCounter(5, time.Hour) },
+	}
+	for name, newStable := range sources {
+		store := &denyStore{}
+		l := limiterUtil.NewLimiter[int](limiterUtil.LimiterConfig{
+			NewStable:     newStable,
+			FailThreshold: 100,
+			QueueCleanup:  time.Hour,
+			Quota:         limiterUtil.NewDistributedBucket(store, "jobs", 10, 1),
+		})
+		for i := 0; i < 5; i++ {
+			if st := l.Submit(i); st == limiterUtil.StateTaken {
+				t.Fatalf("%s: submit %d should not be taken while the quota denies", name, i)
+			}
+		}
+		store.allow.Store(true)
+		for i := 0; i < 5; i++ {
+			if st := l.Submit(i); st != limiterUtil.StateTaken {
+				t.Fatalf("%s: window slot %d was lost to a quota denial, got state %v", name, i, st)
+			}
+		}
+		l.Stop()
+	}
+}
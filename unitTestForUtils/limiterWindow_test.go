@@ -0,0 +1,203 @@
+package unitTestForUtils
+
+import (
+	"testing"
+	"time"
+
+	"github.com/sukasukasuka123/NetUtil/clockutil"
+	"github.com/sukasukasuka123/NetUtil/limiterUtil"
+)
+
+// 从整分钟开始，方便对齐窗口
+var windowStart = time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+
+func TestFixedWindow(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	w := limiterUtil.NewFixedWindow(3, time.Minute)
+	w.SetClock(clock)
+
+	for i := 0; i < 3; i++ {
+		if !w.TryTake(1) {
+			t.Fatalf("take %d should succeed", i)
+		}
+	}
+	if w.TryTake(1) {
+		t.Fatal("4th take in the same window should fail")
+	}
+	clock.Advance(40 * time.Second)
+	if d := w.TimeToTokens(1); d != 20*time.Second {
+		t.Fatalf("expected 20s to next window, got %v", d)
+	}
+	if d := w.TimeToTokens(4); d != -1 {
+		t.Fatalf("expected -1 for n > limit, got %v", d)
+	}
+
+	// 窗口边界后计数清零
+	clock.Advance(20 * time.Second)
+	if got := w.Tokens(); got != 3 {
+		t.Fatalf("expected full window, got %v", got)
+	}
+	if !w.TryTake(3) {
+		t.Fatal("take after reset should succeed")
+	}
+}
+
+func TestSlidingWindowLog(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	w := limiterUtil.NewSlidingWindowLog(3, time.Minute)
+	w.SetClock(clock)
+
+	w.TryTake(1)
+	clock.Advance(20 * time.Second)
+	w.TryTake(2)
+	if w.TryTake(1) {
+		t.Fatal("limit reached, take should fail")
+	}
+
+	// 跨过固定窗口边界也不会重置：第一条记录 60s 后才滑出
+	clock.Advance(30 * time.Second)
+	if d := w.TimeToTokens(1); d != 10*time.Second {
+		t.Fatalf("expected 10s until first entry expires, got %v", d)
+	}
+	if d := w.TimeToTokens(3); d != 30*time.Second {
+		t.Fatalf("expected 30s until all entries expire, got %v", d)
+	}
+	clock.Advance(10 * time.Second)
+	if got := w.Tokens(); got != 1 {
+		t.Fatalf("expected 1 remaining, got %v", got)
+	}
+	if !w.TryTake(1) || w.TryTake(1) {
+		t.Fatal("exactly one slot should be free")
+	}
+}
+
+func TestSlidingWindowCounter(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	w := limiterUtil.NewSlidingWindowCounter(10, time.Minute)
+	w.SetClock(clock)
+
+	if !w.TryTake(10) || w.TryTake(1) {
+		t.Fatal("first window should allow exactly 10")
+	}
+
+	// 下一窗口过去 1/4：上一窗口计入 10*3/4 = 7.5
+	clock.Advance(75 * time.Second)
+	if got := w.Tokens(); got != 2.5 {
+		t.Fatalf("expected 2.5 remaining, got %v", got)
+	}
+	if !w.TryTake(2) || w.TryTake(1) {
+		t.Fatal("expected 2 allowed and 3rd rejected")
+	}
+	// 还需 0.5 个名额，上一窗口权重从 7.5 降到 7 需要 3s
+	if d := w.TimeToTokens(1); d != 3*time.Second {
+		t.Fatalf("expected 3s, got %v", d)
+	}
+
+	// 跳过两个以上窗口后完全清零
+	clock.Advance(3 * time.Minute)
+	if got := w.Tokens(); got != 10 {
+		t.Fatalf("expected full window, got %v", got)
+	}
+}
+
+func TestWindowInTripleBucket(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	tb := limiterUtil.NewTripleBucketWith(
+		limiterUtil.NewSlidingWindowLog(5, time.Minute),
+		limiterUtil.NewFixedWindow(2, time.Second),
+		100, time.Second,
+	)
+	tb.SetClock(clock)
+
+	// 稳定窗口 5 次 + 突发窗口 2 次
+	for i := 0; i < 7; i++ {
+		if !tb.TryTake() {
+			t.Fatalf("take %d should succeed", i)
+		}
+	}
+	if tb.TryTake() {
+		t.Fatal("expected rejection after stable and burst exhausted")
+	}
+	clock.Advance(time.Second)
+	if !tb.TryTake() {
+		t.Fatal("burst window should have reset")
+	}
+	if d := tb.RetryAfter(); d < 0 {
+		t.Fatalf("window sources should support RetryAfter, got %v", d)
+	}
+}
+
+func TestLimiterWithWindowFactory(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	k := limiterUtil.NewKeyedLimiter(limiterUtil.KeyedLimiterConfig{
+		Template: limiterUtil.LimiterConfig{
+			NewStable: func() limiterUtil.TokenSource {
+				return limiterUtil.NewSlidingWindowCounter(3, time.Hour)
+			},
+			BurstCap:      0,
+			FailThreshold: 100,
+			RejectDur:     time.Second,
+			Clock:         clock,
+		},
+	})
+	defer k.Stop()
+
+	for i := 0; i < 3; i++ {
+		if !k.Allow("alice") {
+			t.Fatalf("alice take %d should succeed", i)
+		}
+	}
+	if k.Allow("alice") {
+		t.Fatal("alice should be limited by the hourly window")
+	}
+	// 每个 key 都有独立的窗口实例
+	if !k.Allow("bob") {
+		t.Fatal("bob should get a separate window")
+	}
+	if got := k.Bucket("alice").StableSource().Capacity(); got != 3 {
+		t.Fatalf("expected window capacity 3, got %v", got)
+	}
+}
+
+// 混用时 *Bucket 仍可通过 Stable / Burst 字段访问，窗口通过 BurstSource 访问
+func TestTripleBucketWithKeepsBucketFields(t *testing.T) {
+	stable := limiterUtil.NewBucket(1, 0)
+	burst := limiterUtil.NewFixedWindow(1, time.Minute)
+	tb := limiterUtil.NewTripleBucketWith(stable, burst, 100, time.Second)
+	if tb.Stable != stable || tb.Burst != nil {
+		t.Fatalf("expected Stable to be the bucket and Burst nil, got %v %v", tb.Stable, tb.Burst)
+	}
+	if tb.BurstSource() != limiterUtil.TokenSource(burst) {
+		t.Fatal("BurstSource should return the window")
+	}
+
+	// 已有调用方式不变
+	tb.Stable.SetRate(1000)
+	d := limiterUtil.NewDualBucket(1, 0, 1, 0)
+	d.Burst.SetRate(1000)
+	if !d.TryTake() || !d.TryTake() {
+		t.Fatal("DualBucket should take from both buckets")
+	}
+}
+
+// window <= 0 按 1 秒处理，不会全部放行或出现 NaN
+func TestWindowInvalidDuration(t *testing.T) {
+	clock := clockutil.NewFakeClock(windowStart)
+	fixed := limiterUtil.NewFixedWindow(2, 0)
+	counter := limiterUtil.NewSlidingWindowCounter(2, -time.Second)
+	fixed.SetClock(clock)
+	counter.SetClock(clock)
+
+	for name, w := range map[string]limiterUtil.TokenSource{"fixed": fixed, "counter": counter} {
+		if !w.TryTake(2) || w.TryTake(1) {
+			t.Fatalf("%s: expected exactly 2 allowed", name)
+		}
+		if got := w.Tokens(); got != 0 {
+			t.Fatalf("%s: expected 0 remaining, got %v", name, got)
+		}
+	}
+	clock.Advance(2 * time.Second)
+	if got := counter.Tokens(); got != 2 {
+		t.Fatalf("counter should reset after the default window, got %v", got)
+	}
+}
@@ -0,0 +1,91 @@
+package unitTestForUtils
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"encoding/json"
+	"net/http"
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+const tokenURL = "https://auth.example.com/oauth/token"
+
+func newTestTokenEndpoint(t *testing.T) (*jwtutil.TokenEndpoint, *jwtutil.JWTManager, *ecdsa.PrivateKey) {
+	issuer := newTestManager()
+	clientKey, _ := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	keys := jwtutil.NewKeyRing()
+	if err := keys.AddKey("c2-key", jwt.SigningMethodES256, &clientKey.PublicKey); err != nil {
+		t.Fatalf("AddKey failed: %v", err)
+	}
+
+	reg := jwtutil.NewMemoryClientRegistry()
+	reg.Register(&jwtutil.OAuthClient{ID: "c1", Secret: "s1", AllowedScopes: []string{"orders:read", "orders:write"}})
+	reg.Register(&jwtutil.OAuthClient{ID: "c2", Keys: keys, AllowedScopes: []string{"billing"}})
+	return jwtutil.NewTokenEndpoint(issuer, reg, tokenURL), issuer, clientKey
+}
+
+func TestClientCredentialsSecret(t *testing.T) {
+	e, issuer, _ := newTestTokenEndpoint(t)
+
+	w := postForm(e, url.Values{"grant_type": {"client_credentials"}, "scope": {"orders:read"}}, "c1", "s1")
+	if w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	var resp map[string]interface{}
+	json.Unmarshal(w.Body.Bytes(), &resp)
+	claims, err := issuer.ParseToken(resp["access_token"].(string))
+	if err != nil {
+		t.Fatalf("issued token invalid: %v", err)
+	}
+	if claims["client_id"] != "c1" || claims["scope"] != "orders:read" {
+		t.Errorf("unexpected claims: %v", claims)
+	}
+
+	if w := postForm(e, url.Values{"grant_type": {"client_credentials"}}, "c1", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Errorf("bad secret: expected 401, got %d", w.Code)
+	}
+	if w := postForm(e, url.Values{"grant_type": {"client_credentials"}, "scope": {"admin"}}, "c1", "s1"); w.Code != http.StatusBadRequest {
+		t.Errorf("disallowed scope: expected 400, got %d", w.Code)
+	}
+	if w := postForm(e, url.Values{"grant_type": {"password"}}, "c1", "s1"); w.Code != http.StatusBadRequest {
+		t.Errorf("unsupported grant: expected 400, got %d", w.Code)
+	}
+}
+
+func TestClientCredentialsPrivateKeyJWT(t *testing.T) {
+	e, _, clientKey := newTestTokenEndpoint(t)
+
+	assertion := func(aud string) string {
+		tok := jwt.NewWithClaims(jwt.SigningMethodES256, jwt.MapClaims{
+			"iss": "c2", "sub": "c2", "aud": aud, "jti": "a-1",
+			"exp": time.Now().Add(time.Minute).Unix(),
+		})
+		tok.Header["kid"] = "c2-key"
+		s, _ := tok.SignedString(clientKey)
+		return s
+	}
+	form := func(a string) url.Values {
+		return url.Values{
+			"grant_type":            {"client_credentials"},
+			"client_assertion_type": {"urn:ietf:params:oauth:client-assertion-type:jwt-bearer"},
+			"client_assertion":      {a},
+		}
+	}
+
+	if w := postForm(e, form(assertion("https://other.example.com")), "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("wrong audience: expected 401, got %d", w.Code)
+	}
+	good := assertion(tokenURL)
+	if w := postForm(e, form(good), "", ""); w.Code != http.StatusOK {
+		t.Fatalf("expected 200, got %d: %s", w.Code, w.Body.String())
+	}
+	if w := postForm(e, form(good), "", ""); w.Code != http.StatusUnauthorized {
+		t.Errorf("replayed assertion: expected 401, got %d", w.Code)
+	}
+}
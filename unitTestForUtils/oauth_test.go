@@ -0,0 +1,137 @@
+package unitTestForUtils
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func postForm(h http.Handler, form url.Values, id, secret string) *httptest.ResponseRecorder {
+	r := httptest.NewRequest(http.MethodPost, "/", strings.NewReader(form.Encode()))
+	r.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+	if id != "" {
+		r.SetBasicAuth(id, secret)
+	}
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+	return w
+}
+
+func TestIntrospectionAndRevocation(t *testing.T) {
+	m := newTestManager()
+	store := jwtutil.NewMemoryRevocationStore(time.Minute)
+	defer store.Stop()
+	m.SetRevocationStore(store)
+	clients := jwtutil.StaticClients{"rs": "rs-secret"}
+
+	introspect := jwtutil.IntrospectionHandler(m, clients)
+	revoke := jwtutil.RevocationHandler(m, clients)
+
+	var hits int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&hits, 1)
+		introspect.ServeHTTP(w, r)
+	}))
+	defer srv.Close()
+
+	token, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1", "scope": "read"})
+
+	if w := postForm(introspect, url.Values{"token": {token}}, "rs", "wrong"); w.Code != http.StatusUnauthorized {
+		t.Fatalf("bad client secret: expected 401, got %d", w.Code)
+	}
+
+	client := jwtutil.NewIntrospectionClient(srv.URL, "rs", "rs-secret")
+	res, err := client.Introspect(context.Background(), token)
+	if err != nil {
+		t.Fatalf("Introspect failed: %v", err)
+	}
+	if !res.Active || res.Claims["sub"] != "u1" {
+		t.Fatalf("expected active token for u1, got %+v", res)
+	}
+	client.Introspect(context.Background(), token)
+	if atomic.LoadInt32(&hits) != 1 {
+		t.Errorf("second introspection should hit the cache")
+	}
+
+	// 只能撤销签发给自己的 token
+	owned, _ := m.GenerateToken(jwt.MapClaims{"sub": "u1", "client_id": "app"})
+	w := postForm(revoke, url.Values{"token": {owned}}, "rs", "rs-secret")
+	if w.Code != http.StatusBadRequest || !strings.Contains(w.Body.String(), "unauthorized_client") {
+		t.Fatalf("revoking another client's token: expected 400 unauthorized_client, got %d %s", w.Code, w.Body.String())
+	}
+	if _, err := m.ParseToken(owned); err != nil {
+		t.Fatalf("refused revocation must not revoke the token: %v", err)
+	}
+
+	if w := postForm(revoke, url.Values{"token": {token}}, "rs", "rs-secret"); w.Code != http.StatusOK {
+		t.Fatalf("revocation: expected 200, got %d", w.Code)
+	}
+	if w := postForm(revoke, url.Values{"token": {"garbage"}}, "rs", "rs-secret"); w.Code != http.StatusOK {
+		t.Errorf("revoking invalid token should still return 200, got %d", w.Code)
+	}
+
+	fresh := jwtutil.NewIntrospectionClient(srv.URL, "rs", "rs-secret")
+	res, err = fresh.Introspect(context.Background(), token)
+	if err != nil || res.Active {
+		t.Errorf("revoked token should be inactive, got %+v %v", res, err)
+	}
+}
+
+// PairManager 的端点同样识别 refresh token：内省报告有效，撤销会作废整个家族
+func TestRefreshTokenIntrospectionAndRevocation(t *testing.T) {
+	p := newTestPairManager()
+	clients := jwtutil.StaticClients{"app": "app-secret", "rs": "rs-secret"}
+	introspect := p.IntrospectionHandler(clients)
+	revoke := p.RevocationHandler(clients)
+
+	pair, err := p.IssuePair(jwt.MapClaims{"sub": "u1", "client_id": "app"})
+	if err != nil {
+		t.Fatalf("IssuePair failed: %v", err)
+	}
+	active := func(token, hint string) bool {
+		w := postForm(introspect, url.Values{"token": {token}, "token_type_hint": {hint}}, "app", "app-secret")
+		var resp map[string]interface{}
+		json.NewDecoder(w.Body).Decode(&resp)
+		return resp["active"] == true
+	}
+	// 提示与实际类型不符时也能识别
+	for _, hint := range []string{"refresh_token", "access_token", ""} {
+		if !active(pair.RefreshToken, hint) {
+			t.Fatalf("refresh token should be active (hint %q)", hint)
+		}
+	}
+	if !active(pair.AccessToken, "refresh_token") {
+		t.Fatal("access token should be active despite the refresh hint")
+	}
+
+	if w := postForm(revoke, url.Values{"token": {pair.RefreshToken}}, "rs", "rs-secret"); w.Code != http.StatusBadRequest {
+		t.Fatalf("revoking another client's refresh token: expected 400, got %d", w.Code)
+	}
+	w := postForm(revoke, url.Values{"token": {pair.RefreshToken}, "token_type_hint": {"refresh_token"}}, "app", "app-secret")
+	if w.Code != http.StatusOK {
+		t.Fatalf("revocation: expected 200, got %d %s", w.Code, w.Body.String())
+	}
+	if _, err := p.RefreshPair(pair.RefreshToken); !errors.Is(err, jwtutil.ErrTokenFamilyRevoked) {
+		t.Fatalf("revoked refresh token should not refresh, got %v", err)
+	}
+	if active(pair.RefreshToken, "refresh_token") {
+		t.Fatal("revoked refresh token should be inactive")
+	}
+
+	// 只识别 access token 的端点明确拒绝 refresh token，而不是静默返回 200
+	plain := jwtutil.RevocationHandler(p.Refresh, clients)
+	other, _ := p.IssuePair(jwt.MapClaims{"sub": "u2"})
+	if w := postForm(plain, url.Values{"token": {other.RefreshToken}}, "app", "app-secret"); !strings.Contains(w.Body.String(), "unsupported_token_type") {
+		t.Fatalf("expected unsupported_token_type, got %d %s", w.Code, w.Body.String())
+	}
+}
@@ -0,0 +1,90 @@
+package unitTestForUtils
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/sukasukasuka123/NetUtil/jwtutil"
+)
+
+func TestPasetoLocalAndPublic(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	pub, priv, _ := ed25519.GenerateKey(rand.Reader)
+
+	local, err := jwtutil.NewPasetoLocalManager(key, time.Minute, "paseto")
+	if err != nil {
+		t.Fatalf("NewPasetoLocalManager failed: %v", err)
+	}
+	public, err := jwtutil.NewPasetoPublicManager(priv, time.Minute, "paseto")
+	if err != nil {
+		t.Fatalf("NewPasetoPublicManager failed: %v", err)
+	}
+	verifier, _ := jwtutil.NewPasetoVerifier(pub, "paseto")
+
+	// 同一套接口，切换构造函数即可
+	for name, pair := range map[string][2]jwtutil.TokenManager{
+		"v4.local":  {local, local},
+		"v4.public": {public, verifier},
+	} {
+		token, err := pair[0].GenerateToken(jwt.MapClaims{"user_id": 1, "role": "admin"})
+		if err != nil {
+			t.Fatalf("%s: GenerateToken failed: %v", name, err)
+		}
+		if !strings.HasPrefix(token, name+".") {
+			t.Errorf("%s: unexpected header in %s", name, token)
+		}
+		claims, err := pair[1].ParseToken(token)
+		if err != nil {
+			t.Fatalf("%s: ParseToken failed: %v", name, err)
+		}
+		if claims["role"] != "admin" {
+			t.Errorf("%s: role mismatch: %v", name, claims["role"])
+		}
+
+		w := httptest.NewRecorder()
+		pair[0].SetTokenCookie(w, token)
+		r := httptest.NewRequest(http.MethodGet, "/", nil)
+		r.AddCookie(w.Result().Cookies()[0])
+		if got, _ := pair[1].ReadTokenFromCookie(r); got != token {
+			t.Errorf("%s: cookie round trip failed", name)
+		}
+	}
+
+	if _, err := verifier.GenerateToken(jwt.MapClaims{}); err != jwtutil.ErrNoSigningKey {
+		t.Errorf("verifier should not sign, got %v", err)
+	}
+}
+
+func TestPasetoRejects(t *testing.T) {
+	key := make([]byte, 32)
+	rand.Read(key)
+	local, _ := jwtutil.NewPasetoLocalManager(key, time.Minute, "paseto")
+	_, priv, _ := ed25519.GenerateKey(rand.Reader)
+	public, _ := jwtutil.NewPasetoPublicManager(priv, time.Minute, "paseto")
+
+	token, _ := local.GenerateToken(jwt.MapClaims{})
+	tampered := token[:len(token)-2] + "AA"
+	if tampered == token {
+		tampered = token[:len(token)-2] + "BB"
+	}
+	if _, err := local.ParseToken(tampered); err == nil {
+		t.Errorf("tampered v4.local token should be rejected")
+	}
+	if _, err := public.ParseToken(token); err == nil {
+		t.Errorf("v4.public manager must reject v4.local token")
+	}
+
+	expired, _ := jwtutil.NewPasetoLocalManager(key, -time.Minute, "paseto")
+	token, _ = expired.GenerateToken(jwt.MapClaims{})
+	if _, err := local.ParseToken(token); !errors.Is(err, jwtutil.ErrTokenExpired) {
+		t.Errorf("expected ErrTokenExpired, got %v", err)
+	}
+}